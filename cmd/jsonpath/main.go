@@ -0,0 +1,349 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+// Command jsonpath queries and edits JSON documents with JSONPath.
+//
+// Usage:
+//
+//	jsonpath [flags] query [file ...]
+//	jsonpath [flags] --set path=value [--delete path] [file ...]
+//	jsonpath repl [--history file] file
+//
+// Documents are read from the files, or from stdin when there are none or a
+// file is "-". With --lines every line of the input is a document.
+//
+// Output formats, selected with --output:
+//
+//	json   the query result as JSON (default)
+//	raw    one line per result, strings without quotes
+//	paths  one line per matched node: its normalized path, a tab and its
+//	       value as JSON
+//
+// --set and --delete may be repeated. They are applied in order and the
+// edited documents are printed instead of query results. A --set value is
+// parsed as JSON, or taken as a string when it is not valid JSON.
+//
+// jsonpath repl loads a document once and evaluates the queries typed on
+// stdin, printing the normalized path and value of every match. A line
+// ending with a TAB lists the completions of the partial path before it,
+// and queries are kept in a history, ~/.jsonpath_history by default, that
+// !! and !n run again. Type :help for the commands.
+//
+// The exit status is 0 when the query matched in every document, 1 when
+// it did not match in some document, missing keys and indices included,
+// and 2 on errors, such as steps the query cannot take.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/oliveagle/jsonpath"
+)
+
+const (
+	exitMatch   = 0
+	exitNoMatch = 1
+	exitError   = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// edit is a --set or --delete to apply to every document.
+type edit struct {
+	path  *jsonpath.Compiled
+	value interface{}
+	del   bool
+}
+
+// editFlag is the --set or --delete flag. Both add to the same list of
+// edits, so they apply in the order they are given.
+type editFlag struct {
+	edits *[]edit
+	del   bool
+}
+
+func (f editFlag) String() string { return "" }
+
+func (f editFlag) Set(s string) error {
+	if f.del {
+		path, err := jsonpath.Compile(s)
+		if err != nil {
+			return err
+		}
+		*f.edits = append(*f.edits, edit{path: path, del: true})
+		return nil
+	}
+	e, err := parseSet(s)
+	if err != nil {
+		return err
+	}
+	*f.edits = append(*f.edits, e)
+	return nil
+}
+
+type options struct {
+	lines   bool
+	output  string
+	compact bool
+	query   *jsonpath.Compiled
+	edits   []edit
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "repl" {
+		return runREPL(args[1:], stdin, stdout, stderr)
+	}
+	fs := flag.NewFlagSet("jsonpath", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var opts options
+	fs.BoolVar(&opts.lines, "lines", false, "read JSON Lines, one document per line")
+	fs.BoolVar(&opts.lines, "l", false, "shorthand for --lines")
+	fs.StringVar(&opts.output, "output", "json", "output format: json, raw or paths")
+	fs.StringVar(&opts.output, "o", "json", "shorthand for --output")
+	fs.BoolVar(&opts.compact, "compact", false, "print compact instead of indented JSON")
+	fs.BoolVar(&opts.compact, "c", false, "shorthand for --compact")
+	fs.Var(editFlag{edits: &opts.edits}, "set", "set `path=value`, may be repeated")
+	fs.Var(editFlag{edits: &opts.edits, del: true}, "delete", "delete the values at `path`, may be repeated")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: jsonpath [flags] query [file ...]")
+		fmt.Fprintln(stderr, "       jsonpath [flags] --set path=value [--delete path] [file ...]")
+		fmt.Fprintln(stderr, "       jsonpath repl [--history file] file")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitMatch
+		}
+		return exitError
+	}
+	switch opts.output {
+	case "json", "raw", "paths":
+	default:
+		fmt.Fprintf(stderr, "jsonpath: unknown output format %q\n", opts.output)
+		return exitError
+	}
+
+	files := fs.Args()
+	if len(opts.edits) == 0 {
+		if len(files) == 0 {
+			fs.Usage()
+			return exitError
+		}
+		query, err := jsonpath.Compile(files[0])
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+			return exitError
+		}
+		opts.query, files = query, files[1:]
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	status := exitMatch
+	for _, name := range files {
+		code := processFile(name, stdin, out, stderr, &opts)
+		if code > status {
+			status = code
+		}
+	}
+	return status
+}
+
+// parseSet parses a --set argument. The path ends at the first "=" that is
+// not part of a comparison operator such as "==" or "<=".
+func parseSet(s string) (edit, error) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '=' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '=' {
+			i++
+			continue
+		}
+		if i > 0 && strings.IndexByte("=!<>~", s[i-1]) >= 0 {
+			continue
+		}
+		path, err := jsonpath.Compile(strings.TrimSpace(s[:i]))
+		if err != nil {
+			return edit{}, err
+		}
+		return edit{path: path, value: parseValue(s[i+1:])}, nil
+	}
+	return edit{}, errors.New("expected path=value")
+}
+
+// parseValue decodes s as JSON, falling back to the string itself.
+func parseValue(s string) interface{} {
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil || d.More() {
+		return s
+	}
+	return v
+}
+
+func processFile(name string, stdin io.Reader, out io.Writer, stderr io.Writer, opts *options) int {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+			return exitError
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if !opts.lines {
+		doc, err := decode(r)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonpath: %s: %v\n", name, err)
+			return exitError
+		}
+		return processDoc(doc, "", out, stderr, opts)
+	}
+
+	status := exitMatch
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			code := exitError
+			doc, derr := decode(bytes.NewReader(data))
+			if derr != nil {
+				fmt.Fprintf(stderr, "jsonpath: %s:%d: %v\n", name, line, derr)
+			} else {
+				code = processDoc(doc, fmt.Sprintf("%d\t", line), out, stderr, opts)
+			}
+			if code > status {
+				status = code
+			}
+		}
+		if err == io.EOF {
+			return status
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonpath: %s: %v\n", name, err)
+			return exitError
+		}
+	}
+}
+
+// decode reads a single JSON document, keeping numbers as json.Number so
+// they are printed back unchanged.
+func decode(r io.Reader) (interface{}, error) {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	var doc interface{}
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, errors.New("unexpected data after the document, use --lines for JSON Lines")
+	}
+	return doc, nil
+}
+
+// processDoc queries or edits doc and prints the result. prefix is put in
+// front of paths output to tell the lines of JSON Lines input apart.
+func processDoc(doc interface{}, prefix string, out io.Writer, stderr io.Writer, opts *options) int {
+	if len(opts.edits) > 0 {
+		var err error
+		for _, e := range opts.edits {
+			if e.del {
+				doc, err = e.path.Delete(doc)
+			} else {
+				doc, err = e.path.Set(doc, e.value)
+			}
+			if err != nil {
+				fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+				return exitError
+			}
+		}
+		if err := writeJSON(out, doc, opts.compact || opts.lines); err != nil {
+			fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+			return exitError
+		}
+		return exitMatch
+	}
+
+	if opts.output == "paths" {
+		nodes, err := opts.query.LookupNodes(doc)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+			return exitError
+		}
+		for _, n := range nodes {
+			value, err := json.Marshal(n.Value)
+			if err != nil {
+				fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+				return exitError
+			}
+			fmt.Fprintf(out, "%s%s\t%s\n", prefix, n.Path, value)
+		}
+		if len(nodes) == 0 {
+			return exitNoMatch
+		}
+		return exitMatch
+	}
+
+	values, single, err := opts.query.LookupMatches(doc)
+	if errors.Is(err, jsonpath.ErrNotFound) {
+		// missing keys and indices, which select no nodes with -o paths
+		return exitNoMatch
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+		return exitError
+	}
+	if !single && len(values) == 0 {
+		return exitNoMatch
+	}
+
+	if opts.output == "raw" {
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				fmt.Fprintln(out, s)
+				continue
+			}
+			if err := writeJSON(out, v, true); err != nil {
+				fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+				return exitError
+			}
+		}
+		return exitMatch
+	}
+	var res interface{} = values
+	if single {
+		res = values[0]
+	}
+	if err := writeJSON(out, res, opts.compact || opts.lines); err != nil {
+		fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+		return exitError
+	}
+	return exitMatch
+}
+
+func writeJSON(out io.Writer, v interface{}, compact bool) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
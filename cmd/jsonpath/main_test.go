@@ -0,0 +1,191 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const storeDoc = `{"store": {"book": [
+	{"author": "Nigel Rees", "price": 8.95},
+	{"author": "Evelyn Waugh", "price": 12.99}
+], "bicycle": {"color": "red"}}}`
+
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCLI_EmptyArray(t *testing.T) {
+	tests := []struct {
+		args []string
+		out  string
+	}{
+		{[]string{"-c", "$.tags"}, "[]\n"},
+		{[]string{"-o", "paths", "$.tags"}, "$.tags\t[]\n"},
+		{[]string{"-o", "raw", "$.tags"}, "[]\n"},
+		{[]string{"-o", "raw", "$.list"}, "[\"a\",\"b\"]\n"},
+		{[]string{"-o", "raw", "$.list[*]"}, "a\nb\n"},
+	}
+	for _, tt := range tests {
+		code, out, _ := runCLI(t, `{"tags": [], "list": ["a", "b"]}`, tt.args...)
+		if code != 0 || out != tt.out {
+			t.Errorf("%v: got %d %q, want 0 %q", tt.args, code, out, tt.out)
+		}
+	}
+}
+
+func TestCLI_IndexNull(t *testing.T) {
+	tests := []struct {
+		stdin string
+		args  []string
+	}{
+		{`{"a": null}`, []string{"$.a[0]"}},
+		{`{"a": null}`, []string{"$.a[*]"}},
+		{`{"a": null}`, []string{"$.a[?(@.b)]"}},
+		{`null`, []string{"-o", "paths", "$[0]"}},
+	}
+	for _, tt := range tests {
+		if code, out, _ := runCLI(t, tt.stdin, tt.args...); code != 1 || out != "" {
+			t.Errorf("%s %v: got %d %q, want no match", tt.stdin, tt.args, code, out)
+		}
+	}
+}
+
+func TestCLI_Query(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+		out  string
+	}{
+		{"compact", []string{"-c", "$.store.book[*].author"}, 0, "[\"Nigel Rees\",\"Evelyn Waugh\"]\n"},
+		{"pretty", []string{"$.store.bicycle"}, 0, "{\n  \"color\": \"red\"\n}\n"},
+		{"raw", []string{"-o", "raw", "$.store.book[*].author"}, 0, "Nigel Rees\nEvelyn Waugh\n"},
+		{"raw number", []string{"-o", "raw", "$.store.book[0].price"}, 0, "8.95\n"},
+		{"paths", []string{"-o", "paths", "$.store.book[*].price"}, 0, "$.store.book[0].price\t8.95\n$.store.book[1].price\t12.99\n"},
+		{"missing key", []string{"$.store.car"}, 1, ""},
+		{"missing index", []string{"$.store.book[5]"}, 1, ""},
+		{"empty filter", []string{"$.store.book[?(@.price > 100)]"}, 1, ""},
+		{"paths no match", []string{"-o", "paths", "$.store.car"}, 1, ""},
+		{"paths missing index", []string{"-o", "paths", "$.store.book[5]"}, 1, ""},
+		{"paths key on array", []string{"-o", "paths", "$.store.book.author"}, 0, "$.store.book[0].author\t\"Nigel Rees\"\n$.store.book[1].author\t\"Evelyn Waugh\"\n"},
+		{"unsupported step", []string{"$.store.*"}, 2, ""},
+		{"paths unsupported step", []string{"-o", "paths", "$.store.*"}, 2, ""},
+		{"key on a string", []string{"$.store.bicycle.color.x"}, 2, ""},
+		{"bad query", []string{"store"}, 2, ""},
+		{"bad output", []string{"-o", "yaml", "$"}, 2, ""},
+		{"no query", nil, 2, ""},
+	}
+	for _, tt := range tests {
+		code, out, _ := runCLI(t, storeDoc, tt.args...)
+		if code != tt.code || out != tt.out {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, code, out, tt.code, tt.out)
+		}
+	}
+}
+
+func TestCLI_Lines(t *testing.T) {
+	in := "{\"a\": 1}\n\n{\"a\": \"x\"}\n{\"b\": 2}\n"
+	code, out, _ := runCLI(t, in, "--lines", "$.a")
+	if code != 1 || out != "1\n\"x\"\n" {
+		t.Errorf("got %d %q", code, out)
+	}
+
+	code, out, _ = runCLI(t, in, "-l", "-o", "paths", "$.a")
+	if code != 1 || out != "1\t$.a\t1\n3\t$.a\t\"x\"\n" {
+		t.Errorf("paths: got %d %q", code, out)
+	}
+
+	code, _, _ = runCLI(t, "{\"a\": 1}\n{", "-l", "$.a")
+	if code != 2 {
+		t.Errorf("invalid line: got %d, want 2", code)
+	}
+	code, _, _ = runCLI(t, in, "$.a")
+	if code != 2 {
+		t.Errorf("several documents without --lines: got %d, want 2", code)
+	}
+}
+
+func TestCLI_Edit(t *testing.T) {
+	code, out, errOut := runCLI(t, `{"a": {"b": 1}, "c": [1, 2, 3], "n": 1.50}`,
+		"-c", "--set", "$.a.b=[true]", "--set", "$.a.d=plain text", "--delete", "$.c[0]")
+	want := `{"a":{"b":[true],"d":"plain text"},"c":[2,3],"n":1.50}` + "\n"
+	if code != 0 || out != want {
+		t.Errorf("got %d %q %s, want %q", code, out, errOut, want)
+	}
+
+	code, out, _ = runCLI(t, "{\"a\": 1}\n{\"a\": 2}\n", "-l", "--set", "$.a=0")
+	if code != 0 || out != "{\"a\":0}\n{\"a\":0}\n" {
+		t.Errorf("lines set: got %d %q", code, out)
+	}
+
+	code, _, _ = runCLI(t, `{}`, "--set", "$.a")
+	if code != 2 {
+		t.Errorf("set without value: got %d, want 2", code)
+	}
+
+	// edits apply in the order of the flags
+	code, out, errOut = runCLI(t, `{"a": {"b": 1}}`,
+		"-c", "--delete", "$.a.b", "--set", "$.a.b=2", "--set", "$.x=1", "--delete", "$.x")
+	if want := `{"a":{"b":2}}` + "\n"; code != 0 || out != want {
+		t.Errorf("interleaved edits: got %d %q %s, want %q", code, out, errOut, want)
+	}
+}
+
+func TestCLI_Files(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonpath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "doc.json")
+	if err := ioutil.WriteFile(file, []byte(storeDoc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, _ := runCLI(t, `{"store": {"bicycle": {"color": "blue"}}}`, "-o", "raw", "$.store.bicycle.color", file, "-")
+	if code != 0 || out != "red\nblue\n" {
+		t.Errorf("got %d %q", code, out)
+	}
+	code, _, _ = runCLI(t, "", "$", filepath.Join(dir, "missing.json"))
+	if code != 2 {
+		t.Errorf("missing file: got %d, want 2", code)
+	}
+}
+
+func TestParseSet(t *testing.T) {
+	tests := []struct {
+		arg, path string
+		value     interface{}
+	}{
+		{"$.a=1", "$.a", "1"},
+		{"$.a = \"x\"", "$.a", "x"},
+		{"$.a=x=y", "$.a", "x=y"},
+		{"$.a[?(@.b == 1)].c=", "$.a[?(@.b == 1)].c", ""},
+		{"$.a[?(@.b >= 1)].c={}", "$.a[?(@.b >= 1)].c", map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		e, err := parseSet(tt.arg)
+		if err != nil {
+			t.Errorf("%s: %v", tt.arg, err)
+			continue
+		}
+		if e.path.String() != "Compiled lookup: "+tt.path || fmt.Sprint(e.value) != fmt.Sprint(tt.value) {
+			t.Errorf("%s: got %s %v, want %s %v", tt.arg, e.path, e.value, tt.path, tt.value)
+		}
+	}
+	if _, err := parseSet("$.a[?(@.b == 1)]"); err == nil {
+		t.Errorf("expected an error without a value")
+	}
+}
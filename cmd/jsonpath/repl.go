@@ -0,0 +1,250 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/oliveagle/jsonpath"
+)
+
+// maxHistory is the number of queries kept in the history file.
+const maxHistory = 1000
+
+const replHelp = `Type a query to see the normalized path and value of every match.
+End a line with TAB to list the completions of a partial path, e.g. $.store.<TAB>
+
+  :complete path   list the completions of path
+  :history         list previous queries
+  !!               run the previous query again
+  !n               run query n of the history again
+  :help            show this help
+  :quit            leave, as does end of input
+`
+
+// repl holds the state of an interactive session.
+type repl struct {
+	doc         interface{}
+	out         io.Writer
+	history     []string
+	historyFile string
+}
+
+// runREPL implements `jsonpath repl file.json`. It loads the document once
+// and evaluates queries line by line.
+func runREPL(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("jsonpath repl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	historyFile := fs.String("history", defaultHistoryFile(), "file to keep the query history in, empty to disable")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: jsonpath repl [flags] file")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitMatch
+		}
+		return exitError
+	}
+	if fs.NArg() != 1 {
+		// stdin is where the queries come from
+		fs.Usage()
+		return exitError
+	}
+
+	name := fs.Arg(0)
+	f, err := os.Open(name)
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+		return exitError
+	}
+	doc, err := decode(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonpath: %s: %v\n", name, err)
+		return exitError
+	}
+
+	s := &repl{doc: doc, out: stdout, historyFile: *historyFile}
+	s.history = loadHistory(s.historyFile)
+	s.loop(stdin)
+	if err := s.saveHistory(); err != nil {
+		fmt.Fprintf(stderr, "jsonpath: %v\n", err)
+	}
+	return exitMatch
+}
+
+func (s *repl) loop(in io.Reader) {
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for {
+		fmt.Fprint(s.out, "jsonpath> ")
+		if !sc.Scan() {
+			fmt.Fprintln(s.out)
+			return
+		}
+		line := sc.Text()
+		if strings.HasSuffix(line, "\t") {
+			s.complete(strings.TrimSpace(line))
+			continue
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case line == ":quit" || line == ":q" || line == ":exit":
+			return
+		case line == ":help":
+			fmt.Fprint(s.out, replHelp)
+		case line == ":history":
+			for i, q := range s.history {
+				fmt.Fprintf(s.out, "%5d  %s\n", i+1, q)
+			}
+		case line == ":complete" || strings.HasPrefix(line, ":complete "):
+			s.complete(strings.TrimSpace(strings.TrimPrefix(line, ":complete")))
+		case strings.HasPrefix(line, "!"):
+			query, ok := s.recall(line)
+			if !ok {
+				fmt.Fprintf(s.out, "no history entry %s\n", line)
+				continue
+			}
+			fmt.Fprintln(s.out, query)
+			s.eval(query)
+		case strings.HasPrefix(line, ":"):
+			fmt.Fprintf(s.out, "unknown command %s, try :help\n", line)
+		default:
+			s.eval(line)
+		}
+	}
+}
+
+// eval runs query like the command line does and prints the normalized
+// path and value of the matches.
+func (s *repl) eval(query string) {
+	s.addHistory(query)
+	c, err := jsonpath.Compile(query)
+	if err != nil {
+		fmt.Fprintf(s.out, "error: %v\n", err)
+		return
+	}
+	res, err := c.Lookup(s.doc)
+	if errors.Is(err, jsonpath.ErrNotFound) {
+		fmt.Fprintln(s.out, "no match")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(s.out, "error: %v\n", err)
+		return
+	}
+	nodes, err := c.LookupNodes(s.doc)
+	if err != nil {
+		// the results of functions have no path
+		if err := writeJSON(s.out, res, false); err != nil {
+			fmt.Fprintf(s.out, "error: %v\n", err)
+		}
+		return
+	}
+	if len(nodes) == 0 {
+		fmt.Fprintln(s.out, "no match")
+		return
+	}
+	for _, n := range nodes {
+		value, err := json.Marshal(n.Value)
+		if err != nil {
+			fmt.Fprintf(s.out, "error: %v\n", err)
+			return
+		}
+		fmt.Fprintf(s.out, "%s\t%s\n", n.Path, value)
+	}
+}
+
+// complete lists the completions of partial, or all keys of the document
+// when partial is empty.
+func (s *repl) complete(partial string) {
+	completions := jsonpath.Complete(s.doc, partial)
+	if len(completions) == 0 {
+		fmt.Fprintln(s.out, "no completions")
+		return
+	}
+	for _, c := range completions {
+		fmt.Fprintln(s.out, c)
+	}
+}
+
+// recall resolves !! and !n to a query of the history.
+func (s *repl) recall(line string) (string, bool) {
+	if len(s.history) == 0 {
+		return "", false
+	}
+	if line == "!!" {
+		return s.history[len(s.history)-1], true
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(s.history) {
+		return "", false
+	}
+	return s.history[n-1], true
+}
+
+func (s *repl) addHistory(query string) {
+	if len(s.history) > 0 && s.history[len(s.history)-1] == query {
+		return
+	}
+	s.history = append(s.history, query)
+}
+
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".jsonpath_history")
+}
+
+// loadHistory reads the queries of previous sessions, one per line. A
+// missing or unreadable file starts an empty history.
+func loadHistory(file string) []string {
+	if file == "" {
+		return nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var history []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			history = append(history, line)
+		}
+	}
+	return history
+}
+
+func (s *repl) saveHistory() error {
+	if s.historyFile == "" || len(s.history) == 0 {
+		return nil
+	}
+	history := s.history
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	var sb strings.Builder
+	for _, q := range history {
+		sb.WriteString(q)
+		sb.WriteString("\n")
+	}
+	return ioutil.WriteFile(s.historyFile, []byte(sb.String()), 0600)
+}
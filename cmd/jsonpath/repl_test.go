@@ -0,0 +1,87 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestREPL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonpath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "doc.json")
+	if err := ioutil.WriteFile(file, []byte(storeDoc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	history := filepath.Join(dir, "history")
+	if err := ioutil.WriteFile(history, []byte("$.store.bicycle.color\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		"$.store.book[*].author",
+		"$.store.car",
+		"$.store.b\t",
+		":complete $.store.book.p",
+		"!1",
+		"$.store.book.length()",
+		"$.store.*",
+		"store",
+		":history",
+		":nope",
+		"!9",
+		":quit",
+		"$.never",
+	}, "\n")
+	code, out, errOut := runCLI(t, input, "repl", "--history", history, file)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, errOut)
+	}
+	for _, want := range []string{
+		"$.store.book[0].author\t\"Nigel Rees\"\n$.store.book[1].author\t\"Evelyn Waugh\"\n",
+		"no match\n",
+		"$.store.bicycle\n$.store.book\n",
+		"$.store.book.price\n",
+		"$.store.bicycle.color\n$.store.bicycle.color\t\"red\"\n",
+		"2\n",
+		"error: unsupported jsonpath operation: scan\n",
+		"error: ",
+		"    1  $.store.bicycle.color\n    2  $.store.book[*].author\n    3  $.store.car\n",
+		"unknown command :nope",
+		"no history entry !9",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "$.never") {
+		t.Errorf("query after :quit was evaluated")
+	}
+
+	saved, err := ioutil.ReadFile(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "$.store.bicycle.color\n$.store.book[*].author\n$.store.car\n$.store.bicycle.color\n$.store.book.length()\n$.store.*\nstore\n"
+	if string(saved) != want {
+		t.Errorf("history file %q, want %q", saved, want)
+	}
+}
+
+func TestREPL_Usage(t *testing.T) {
+	if code, _, _ := runCLI(t, `{}`, "repl", "--history", ""); code != 2 {
+		t.Errorf("without file: got %d, want 2", code)
+	}
+	if code, _, _ := runCLI(t, "", "repl", "--history", "", "missing.json"); code != 2 {
+		t.Errorf("missing file: got %d, want 2", code)
+	}
+}
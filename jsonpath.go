@@ -7,10 +7,14 @@
 package jsonpath
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go/token"
 	"go/types"
+	"math"
+	"math/big"
 	"reflect"
 	"regexp"
 	"sort"
@@ -18,11 +22,132 @@ import (
 	"strings"
 )
 
-var ErrGetFromNullObj = errors.New("get attribute from null object")
+// ErrNotFound is matched by errors.Is for the errors of lookups of keys
+// that an object does not have, of indices out of range of an array, and
+// for ErrGetFromNullObj.
+var ErrNotFound = errors.New("not found")
+
+var ErrGetFromNullObj error = notFoundError("get attribute from null object")
 var ErrKeyError = errors.New("key error: %s not found in object")
 
+// notFoundError is an error of a key or index that does not exist.
+type notFoundError string
+
+func (e notFoundError) Error() string { return string(e) }
+
+// Is makes notFoundErrors match ErrNotFound.
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+func notFound(format string, args ...interface{}) error {
+	return notFoundError(fmt.Sprintf(format, args...))
+}
+
+// ctxCheckInterval is the number of visited nodes between two checks of
+// the evaluation context.
+const ctxCheckInterval = 256
+
+// evaluator holds the state of a single evaluation. A nil *evaluator
+// evaluates without any checks.
+type evaluator struct {
+	ctx    context.Context
+	limits EvalLimits
+	cycles CyclePolicy
+	mode   SetMode
+	ticks  int
+	depth  int
+
+	// deleting makes the set functions remove the selected values, and
+	// patch records their edits at the JSON Pointer location loc
+	deleting bool
+	patch    *Patch
+	loc      []interface{}
+
+	// creating makes the set functions create missing keys, elements and
+	// containers on the way to the selected values
+	creating bool
+
+	// filtered, if set, is given the result of filter predicates for the
+	// element or member seg of the filtered value, with the error that
+	// stopped the filter or the one of a missing operand
+	filtered func(seg interface{}, ok bool, err error)
+}
+
+func (c *Compiled) newEvaluator(ctx context.Context) *evaluator {
+	if ctx == nil && c.limits == (EvalLimits{}) && c.cycles == CycleSkip && c.mode == SetCopy {
+		return nil
+	}
+	return &evaluator{ctx: ctx, limits: c.limits, cycles: c.cycles, mode: c.mode}
+}
+
+// tick is called for every visited node. It enforces MaxNodesVisited and
+// periodically reports whether the evaluation context is done.
+func (ev *evaluator) tick() error {
+	if ev == nil {
+		return nil
+	}
+	ev.ticks++
+	if ev.limits.MaxNodesVisited > 0 && ev.ticks > ev.limits.MaxNodesVisited {
+		return &ErrLimitExceeded{Limit: "MaxNodesVisited", Max: ev.limits.MaxNodesVisited}
+	}
+	if ev.ctx == nil || ev.ticks%ctxCheckInterval != 1 {
+		return nil
+	}
+	select {
+	case <-ev.ctx.Done():
+		return ev.ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// stops tells whether err stops the evaluation, as opposed to an error
+// of a lookup that can be skipped.
+func (ev *evaluator) stops(err error) bool {
+	if ev == nil {
+		return false
+	}
+	var limit *ErrLimitExceeded
+	return errors.As(err, &limit) || ev.ctx != nil && err == ev.ctx.Err()
+}
+
+// enter and leave bracket a recursion level, enforcing MaxDepth.
+func (ev *evaluator) enter() error {
+	if ev == nil {
+		return nil
+	}
+	ev.depth++
+	if ev.limits.MaxDepth > 0 && ev.depth > ev.limits.MaxDepth {
+		return &ErrLimitExceeded{Limit: "MaxDepth", Max: ev.limits.MaxDepth}
+	}
+	return nil
+}
+
+func (ev *evaluator) leave() {
+	if ev != nil {
+		ev.depth--
+	}
+}
+
+// cycle is called when a traversal reaches a value it is already inside of.
+// A nil error means the value is skipped.
+func (ev *evaluator) cycle() error {
+	if ev != nil && ev.cycles == CycleError {
+		return ErrCycle
+	}
+	return nil
+}
+
+// result enforces MaxResults on the n-th value of a step, checked by the
+// steps collecting many values before they add it.
+func (ev *evaluator) result(n int) error {
+	if ev == nil || ev.limits.MaxResults <= 0 || n <= ev.limits.MaxResults {
+		return nil
+	}
+	return &ErrLimitExceeded{Limit: "MaxResults", Max: ev.limits.MaxResults}
+}
+
 func JsonPathLookup(obj interface{}, jpath string) (interface{}, error) {
-	c, err := Compile(jpath)
+	c, err := compileDefault(jpath)
 	if err != nil {
 		return nil, err
 	}
@@ -30,8 +155,11 @@ func JsonPathLookup(obj interface{}, jpath string) (interface{}, error) {
 }
 
 type Compiled struct {
-	path  string
-	steps []step
+	path   string
+	steps  []step
+	limits EvalLimits
+	cycles CyclePolicy
+	mode   SetMode
 }
 
 type step struct {
@@ -79,112 +207,229 @@ func (c *Compiled) String() string {
 }
 
 func (c *Compiled) Lookup(obj interface{}) (interface{}, error) {
+	return c.lookup(c.newEvaluator(nil), obj)
+}
+
+// LookupContext is like Lookup but stops with ctx.Err() once ctx is done.
+// The context is checked periodically while descending, scanning and
+// filtering, so long running queries over large documents can be aborted.
+func (c *Compiled) LookupContext(ctx context.Context, obj interface{}) (interface{}, error) {
+	return c.lookup(c.newEvaluator(ctx), obj)
+}
+
+func (c *Compiled) lookup(ev *evaluator, obj interface{}) (interface{}, error) {
+	t, err := c.lookupTraced(ev, tracedValue{value: obj})
+	if err != nil {
+		return nil, err
+	}
+	return t.value, nil
+}
+
+// lookupTraced applies the steps to t, keeping track of the locations of
+// the values if t does.
+func (c *Compiled) lookupTraced(ev *evaluator, t tracedValue) (tracedValue, error) {
 	var err error
 	for i, s := range c.steps {
-		// "key", "idx"
-		switch s.op {
-		case "key":
-			obj, err = get_key(obj, s.key)
-			if err != nil {
-				return nil, err
-			}
-		case "idx":
-			if len(s.key) > 0 {
-				// no key `$[0].test`
-				obj, err = get_key(obj, s.key)
-				if err != nil {
-					return nil, err
-				}
-			}
+		if err := ev.tick(); err != nil {
+			return tracedValue{}, err
+		}
+		t, err = lookupStep(ev, t, s, i+1 < len(c.steps) && c.steps[i+1].op == "key")
+		if err != nil {
+			return tracedValue{}, err
+		}
+	}
+	return t, nil
+}
 
-			if len(s.args.([]int)) > 1 {
-				res := []interface{}{}
-				for _, x := range s.args.([]int) {
-					//fmt.Println("idx ---- ", x)
-					tmp, err := get_idx(obj, x)
-					if err != nil {
-						return nil, err
-					}
-					res = append(res, tmp)
-				}
-				obj = res
-			} else if len(s.args.([]int)) == 1 {
-				//fmt.Println("idx ----------------3")
-				obj, err = get_idx(obj, s.args.([]int)[0])
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				//fmt.Println("idx ----------------4")
-				return nil, fmt.Errorf("cannot index on empty slice")
+// tracedValue is a result of lookup: a value of the document, or a list of
+// results like the ones ranges, filters, recursive descent and keys applied
+// to arrays make. If locations are tracked, from tells where it comes from.
+type tracedValue struct {
+	value interface{}
+	from  *origin
+}
+
+// origin is where a traced value comes from: loc is the location of a
+// value of the document and items are the results of a list.
+type origin struct {
+	loc   []interface{}
+	list  bool
+	items []tracedValue
+}
+
+// traced returns obj as the root of a document whose locations are tracked.
+func traced(obj interface{}) tracedValue {
+	return tracedValue{value: obj, from: &origin{loc: []interface{}{}}}
+}
+
+// child returns value, found at seg in t.
+func (t tracedValue) child(seg interface{}, value interface{}) tracedValue {
+	if t.from == nil {
+		return tracedValue{value: value}
+	}
+	loc := make([]interface{}, len(t.from.loc)+1)
+	copy(loc, t.from.loc)
+	loc[len(t.from.loc)] = seg
+	return tracedValue{value: value, from: &origin{loc: loc}}
+}
+
+// member returns value, the member name of t. Unlike child, it does not
+// box name unless locations are tracked.
+func (t tracedValue) member(name string, value interface{}) tracedValue {
+	if t.from == nil {
+		return tracedValue{value: value}
+	}
+	return t.child(name, value)
+}
+
+// deref returns value, which t holds through a pointer or an interface.
+func (t tracedValue) deref(value interface{}) tracedValue {
+	return tracedValue{value: value, from: t.from}
+}
+
+// elem returns the element i of t, whose value v is an array.
+func (t tracedValue) elem(v reflect.Value, i int) tracedValue {
+	if t.from == nil {
+		return tracedValue{value: v.Index(i).Interface()}
+	}
+	if t.from.list {
+		return t.from.items[i]
+	}
+	return t.child(i, v.Index(i).Interface())
+}
+
+// item returns the result i of the list t.
+func (t tracedValue) item(i int) tracedValue {
+	if t.from != nil {
+		return t.from.items[i]
+	}
+	return tracedValue{value: t.value.([]interface{})[i]}
+}
+
+// count returns the number of values of the document t, whose locations
+// are tracked, is made of. Computed results count as one.
+func (t tracedValue) count() int {
+	if t.from == nil || !t.from.list {
+		return 1
+	}
+	n := 0
+	for _, item := range t.from.items {
+		n += item.count()
+	}
+	return n
+}
+
+// tracedList collects the results of a list.
+type tracedList struct {
+	track  bool
+	values []interface{}
+	items  []tracedValue
+}
+
+func newTracedList(t tracedValue, values []interface{}) tracedList {
+	return tracedList{track: t.from != nil, values: values}
+}
+
+func (l *tracedList) add(t tracedValue) {
+	l.values = append(l.values, t.value)
+	if l.track {
+		l.items = append(l.items, t)
+	}
+}
+
+func (l *tracedList) result() tracedValue {
+	if !l.track {
+		return tracedValue{value: l.values}
+	}
+	return tracedValue{value: l.values, from: &origin{list: true, items: l.items}}
+}
+
+// lookupStep applies the step s to t. beforeKey tells whether a key step
+// follows, which recursive descent takes into account.
+func lookupStep(ev *evaluator, t tracedValue, s step, beforeKey bool) (tracedValue, error) {
+	var err error
+	// "key", "idx"
+	switch s.op {
+	case "key":
+		return lookupKey(ev, t, s.key)
+	case "member":
+		return lookupMember(t, s.key)
+	case "idx":
+		if len(s.key) > 0 {
+			// no key `$[0].test`
+			if t, err = lookupKey(ev, t, s.key); err != nil {
+				return tracedValue{}, err
 			}
-		case "range":
-			if len(s.key) > 0 {
-				// no key `$[:1].test`
-				obj, err = get_key(obj, s.key)
-				if err != nil {
-					return nil, err
+		}
+
+		indices := s.args.([]int)
+		if len(indices) > 1 {
+			res := newTracedList(t, []interface{}{})
+			for _, x := range indices {
+				if err := ev.result(len(res.values) + 1); err != nil {
+					return tracedValue{}, err
 				}
-			}
-			if argsv, ok := s.args.([2]interface{}); ok == true {
-				obj, err = get_range(obj, argsv[0], argsv[1])
+				tmp, err := lookupIdx(t, x)
 				if err != nil {
-					return nil, err
-				}
-			} else {
-				return nil, fmt.Errorf("range args length should be 2")
-			}
-		case "filter":
-			obj, err = get_key(obj, s.key)
-			if err != nil {
-				return nil, err
-			}
-			obj, err = get_filtered(obj, obj, s.args.(string))
-			if err != nil {
-				return nil, err
-			}
-		case "recursive":
-			obj = getAllDescendants(obj)
-			// Heuristic: if next step is key, exclude slices from candidates to avoid double-matching
-			// (once as container via implicit map, once as individual elements)
-			if i+1 < len(c.steps) && c.steps[i+1].op == "key" {
-				if candidates, ok := obj.([]interface{}); ok {
-					filtered := []interface{}{}
-					for _, cand := range candidates {
-						// Filter out Slices (but keep Maps and others)
-						// because get_key on Slice iterates children, which are already in candidates
-						v := reflect.ValueOf(cand)
-						if v.Kind() != reflect.Slice {
-							filtered = append(filtered, cand)
-						}
-					}
-					obj = filtered
+					return tracedValue{}, err
 				}
+				res.add(tmp)
 			}
-		case "func":
-			// Handle function calls like length()
-			// For function calls like $.length(), the key is the function name (e.g., "length")
-			// For path-based function calls like $.store.book.length(), the key is empty
-			// and we need to evaluate the function on the current object
-			if len(s.key) > 0 {
-				// This case handles paths like $.store.book.length() where the function
-				// is called on the result of the previous path step
-				obj, err = eval_func(obj, s.key)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				// This case handles direct function calls like $.length() or @.length()
-				obj, err = eval_func(obj, s.key)
-				if err != nil {
-					return nil, err
+			return res.result(), nil
+		} else if len(indices) == 1 {
+			return lookupIdx(t, indices[0])
+		}
+		return tracedValue{}, fmt.Errorf("cannot index on empty slice")
+	case "range":
+		if len(s.key) > 0 {
+			// no key `$[:1].test`
+			if t, err = lookupKey(ev, t, s.key); err != nil {
+				return tracedValue{}, err
+			}
+		}
+		argsv, ok := s.args.([2]interface{})
+		if !ok {
+			return tracedValue{}, fmt.Errorf("range args length should be 2")
+		}
+		return lookupRange(ev, t, argsv[0], argsv[1])
+	case "filter":
+		if t, err = lookupKey(ev, t, s.key); err != nil {
+			return tracedValue{}, err
+		}
+		return lookupFiltered(ev, t, t.value, s.args.(string))
+	case "recursive":
+		if t, err = lookupDescendants(ev, t); err != nil {
+			return tracedValue{}, err
+		}
+		// Heuristic: if next step is key, exclude slices from candidates to avoid double-matching
+		// (once as container via implicit map, once as individual elements)
+		if beforeKey {
+			filtered := newTracedList(t, []interface{}{})
+			for i, cand := range t.value.([]interface{}) {
+				// Filter out Slices (but keep Maps and others)
+				// because get_key on Slice iterates children, which are already in candidates
+				v := reflect.ValueOf(cand)
+				if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+					filtered.add(t.item(i))
 				}
 			}
-		default:
-			return nil, fmt.Errorf("unsupported jsonpath operation: %s", s.op)
+			t = filtered.result()
 		}
+		return t, nil
+	case "func":
+		// Handle function calls like length()
+		// For function calls like $.length(), the key is the function name (e.g., "length")
+		// For path-based function calls like $.store.book.length(), the key is empty
+		// and we need to evaluate the function on the current object.
+		// The result is computed, so it has no location.
+		obj, err := eval_func(t.value, s.key)
+		if err != nil {
+			return tracedValue{}, err
+		}
+		return tracedValue{value: obj}, nil
+	default:
+		return tracedValue{}, fmt.Errorf("unsupported jsonpath operation: %s", s.op)
 	}
-	return obj, nil
 }
 
 func tokenize(query string) ([]string, error) {
@@ -193,11 +438,21 @@ func tokenize(query string) ([]string, error) {
 	//	token_end := false
 	token := ""
 	quoteChar := rune(0)
+	// quoted keys, unlike quoted filter operands, take \" and \\ as escapes
+	quotedKey, escaped := false, false
 
 	// fmt.Println("-------------------------------------------------- start")
 	for idx, x := range query {
 		if quoteChar != 0 {
-			if x == quoteChar {
+			if escaped {
+				if x != quoteChar && x != '\\' {
+					token += "\\"
+				}
+				token += string(x)
+				escaped = false
+			} else if quotedKey && x == '\\' {
+				escaped = true
+			} else if x == quoteChar {
 				quoteChar = 0
 			} else {
 				token += string(x)
@@ -210,6 +465,7 @@ func tokenize(query string) ([]string, error) {
 			}
 
 			quoteChar = x
+			quotedKey = token == ""
 			continue
 		}
 
@@ -437,8 +693,15 @@ func filter_get_from_explicit_path(obj interface{}, path string) (interface{}, e
 }
 
 func get_key(obj interface{}, key string) (interface{}, error) {
+	t, err := lookupKey(nil, tracedValue{value: obj}, key)
+	return t.value, err
+}
+
+// lookupKey is get_key on traced values.
+func lookupKey(ev *evaluator, t tracedValue, key string) (tracedValue, error) {
+	obj := t.value
 	if reflect.TypeOf(obj) == nil {
-		return nil, ErrGetFromNullObj
+		return tracedValue{}, ErrGetFromNullObj
 	}
 	value := reflect.ValueOf(obj)
 	switch value.Kind() {
@@ -449,161 +712,192 @@ func get_key(obj interface{}, key string) (interface{}, error) {
 		if jsonMap, ok := obj.(map[string]interface{}); ok {
 			val, exists := jsonMap[key]
 			if !exists {
-				return nil, fmt.Errorf("key error: %s not found in object", key)
+				return tracedValue{}, notFound("key error: %s not found in object", key)
 			}
-			return val, nil
+			return t.member(key, val), nil
 		}
-		for _, kv := range value.MapKeys() {
-			//fmt.Println(kv.String())
-			if kv.String() == key {
-				return value.MapIndex(kv).Interface(), nil
+		if v, ok := mapIndex(value, key); ok {
+			if t.from == nil {
+				// spare resolving the name
+				return tracedValue{value: v.Interface()}, nil
 			}
+			return t.child(childName(obj, key), v.Interface()), nil
 		}
-		return nil, fmt.Errorf("key error: %s not found in object", key)
-	case reflect.Slice:
+		return tracedValue{}, notFound("key error: %s not found in object", key)
+	case reflect.Slice, reflect.Array:
 		// slice we should get from all objects in it.
 		// if key is empty, return the slice itself (for root array filtering)
 		if key == "" {
-			return obj, nil
+			return t, nil
 		}
-		res := []interface{}{}
+		res := newTracedList(t, []interface{}{})
 		for i := 0; i < value.Len(); i++ {
-			tmp, _ := get_idx(obj, i)
-			if v, err := get_key(tmp, key); err == nil {
-				res = append(res, v)
+			if err := ev.tick(); err != nil {
+				return tracedValue{}, err
+			}
+			if v, err := lookupKey(ev, t.elem(value, i), key); err == nil {
+				if err := ev.result(len(res.values) + 1); err != nil {
+					return tracedValue{}, err
+				}
+				res.add(v)
+			} else if ev.stops(err) {
+				return tracedValue{}, err
 			}
 		}
-		return res, nil
+		return res.result(), nil
 	case reflect.Ptr:
 		// Unwrap pointer
 		realValue := value.Elem()
 
 		if !realValue.IsValid() {
-			return nil, fmt.Errorf("null pointer")
+			return tracedValue{}, fmt.Errorf("null pointer")
 		}
 
-		return get_key(realValue.Interface(), key)
+		return lookupKey(ev, t.deref(realValue.Interface()), key)
 	case reflect.Interface:
 		// Unwrap interface value
 		realValue := value.Elem()
 
-		return get_key(realValue.Interface(), key)
+		return lookupKey(ev, t.deref(realValue.Interface()), key)
 	case reflect.Struct:
-		for i := 0; i < value.NumField(); i++ {
-			valueField := value.Field(i)
-			structField := value.Type().Field(i)
-
-			// Embeded struct
-			if valueField.Kind() == reflect.Struct && structField.Anonymous {
-				v, _ := get_key(valueField.Interface(), key)
-				if v != nil {
-					return v, nil
-				}
-			} else {
-				if structField.Name == key {
-					return valueField.Interface(), nil
-				}
-
-				if tag := structField.Tag.Get("json"); tag != "" {
-					values := strings.Split(tag, ",")
-					for _, tagValue := range values {
-						// In the following cases json tag names should not be checked:
-						// ",omitempty", "-", "-,"
-						if (tagValue == "" && len(values) == 2) || tagValue == "-" {
-							break
-						}
-						if tagValue != "omitempty" && tagValue == key {
-							return valueField.Interface(), nil
-						}
-					}
-				}
-			}
+		// resolve the key like encoding/json resolves object keys
+		f, ok := lookupField(value.Type(), key)
+		if !ok {
+			return tracedValue{}, notFound("key error: %s not found in struct", key)
 		}
-
-		return nil, fmt.Errorf("key error: %s not found in struct", key)
+		fv, ok := fieldByIndex(value, f.index)
+		if !ok {
+			// promoted through a nil embedded pointer
+			return tracedValue{}, ErrGetFromNullObj
+		}
+		return t.member(f.name, fv.Interface()), nil
 	default:
-		return nil, fmt.Errorf("object is not map")
+		return tracedValue{}, fmt.Errorf("object is not map")
 	}
 }
 
 func get_idx(obj interface{}, idx int) (interface{}, error) {
-	switch reflect.TypeOf(obj).Kind() {
-	case reflect.Slice:
-		length := reflect.ValueOf(obj).Len()
+	t, err := lookupIdx(tracedValue{value: obj}, idx)
+	return t.value, err
+}
+
+// lookupIdx is get_idx on traced values.
+func lookupIdx(t tracedValue, idx int) (tracedValue, error) {
+	if reflect.TypeOf(t.value) == nil {
+		return tracedValue{}, ErrGetFromNullObj
+	}
+	switch reflect.TypeOf(t.value).Kind() {
+	case reflect.Slice, reflect.Array:
+		v := reflect.ValueOf(t.value)
+		length := v.Len()
 		if idx >= 0 {
 			if idx >= length {
-				return nil, fmt.Errorf("index out of range: len: %v, idx: %v", length, idx)
+				return tracedValue{}, notFound("index out of range: len: %v, idx: %v", length, idx)
 			}
-			return reflect.ValueOf(obj).Index(idx).Interface(), nil
+			return t.elem(v, idx), nil
 		} else {
 			// < 0
 			_idx := length + idx
 			if _idx < 0 {
-				return nil, fmt.Errorf("index out of range: len: %v, idx: %v", length, idx)
+				return tracedValue{}, notFound("index out of range: len: %v, idx: %v", length, idx)
 			}
-			return reflect.ValueOf(obj).Index(_idx).Interface(), nil
+			return t.elem(v, _idx), nil
 		}
 	default:
-		return nil, fmt.Errorf("object is not Slice")
+		return tracedValue{}, fmt.Errorf("object is not Slice")
 	}
 }
 
-func get_range(obj, frm, to interface{}) (interface{}, error) {
+func get_range(ev *evaluator, obj, frm, to interface{}) (interface{}, error) {
+	t, err := lookupRange(ev, tracedValue{value: obj}, frm, to)
+	return t.value, err
+}
+
+// lookupRange is get_range on traced values.
+func lookupRange(ev *evaluator, t tracedValue, frm, to interface{}) (tracedValue, error) {
+	obj := t.value
+	if reflect.TypeOf(obj) == nil {
+		return tracedValue{}, ErrGetFromNullObj
+	}
 	switch reflect.TypeOf(obj).Kind() {
-	case reflect.Slice:
-		length := reflect.ValueOf(obj).Len()
-		_frm := 0
-		_to := length
-		if frm == nil {
-			frm = 0
-		}
-		if to == nil {
-			to = length
-		}
-		if fv, ok := frm.(int); ok == true {
-			if fv < 0 {
-				_frm = length + fv
-			} else {
-				_frm = fv
-			}
-		}
-		if tv, ok := to.(int); ok == true {
-			if tv < 0 {
-				_to = length + tv + 1
-			} else {
-				_to = tv
-			}
+	case reflect.Slice, reflect.Array:
+		v := reflect.ValueOf(obj)
+		_frm, _to, err := rangeIndices(v.Len(), frm, to)
+		if err != nil {
+			return tracedValue{}, err
 		}
-		if _frm < 0 || _frm >= length {
-			return nil, fmt.Errorf("index [from] out of range: len: %v, from: %v", length, frm)
+		//fmt.Println("_frm, _to: ", _frm, _to)
+		if err := ev.result(_to - _frm); err != nil {
+			return tracedValue{}, err
 		}
-		// Clamp _to to valid range [0, length] per RFC 9535
-		if _to < 0 {
-			_to = 0
+		res := tracedValue{}
+		if t.from != nil {
+			res.from = &origin{list: true}
+			for i := _frm; i < _to; i++ {
+				res.from.items = append(res.from.items, t.elem(v, i))
+			}
 		}
-		if _to > length {
-			_to = length
+		if v.Kind() == reflect.Array {
+			// only addressable arrays can be sliced
+			addressable := reflect.New(v.Type()).Elem()
+			addressable.Set(v)
+			v = addressable
 		}
-		//fmt.Println("_frm, _to: ", _frm, _to)
-		res_v := reflect.ValueOf(obj).Slice(_frm, _to)
-		return res_v.Interface(), nil
+		res.value = v.Slice(_frm, _to).Interface()
+		return res, nil
 	case reflect.Map:
-		// For wildcard [*] on maps, return all values
-		var res []interface{}
-		if jsonMap, ok := obj.(map[string]interface{}); ok {
-			for _, v := range jsonMap {
-				res = append(res, v)
+		// For wildcard [*] on maps, return all values in key order
+		res := newTracedList(t, nil)
+		v := reflect.ValueOf(obj)
+		keys, names := sortedMapKeys(v)
+		for i, k := range keys {
+			if err := ev.result(len(res.values) + 1); err != nil {
+				return tracedValue{}, err
 			}
-			return res, nil
+			res.add(t.member(names[i], v.MapIndex(k).Interface()))
 		}
-		keys := reflect.ValueOf(obj).MapKeys()
-		for _, k := range keys {
-			res = append(res, reflect.ValueOf(obj).MapIndex(k).Interface())
-		}
-		return res, nil
+		return res.result(), nil
 	default:
-		return nil, fmt.Errorf("object is not Slice")
+		return tracedValue{}, fmt.Errorf("object is not Slice")
+	}
+}
+
+// rangeIndices resolves the bounds of a range over length elements the way
+// get_range does, negative bounds counting from the end.
+func rangeIndices(length int, frm, to interface{}) (int, int, error) {
+	_frm := 0
+	_to := length
+	if frm == nil {
+		frm = 0
+	}
+	if to == nil {
+		to = length
+	}
+	if fv, ok := frm.(int); ok == true {
+		if fv < 0 {
+			_frm = length + fv
+		} else {
+			_frm = fv
+		}
+	}
+	if tv, ok := to.(int); ok == true {
+		if tv < 0 {
+			_to = length + tv + 1
+		} else {
+			_to = tv
+		}
+	}
+	if _frm < 0 || _frm >= length {
+		return 0, 0, notFound("index [from] out of range: len: %v, from: %v", length, frm)
+	}
+	// Clamp _to to valid range [0, length] per RFC 9535
+	if _to < 0 {
+		_to = 0
 	}
+	if _to > length {
+		_to = length
+	}
+	return _frm, _to, nil
 }
 
 func regFilterCompile(rule string) (*regexp.Regexp, error) {
@@ -619,84 +913,145 @@ func regFilterCompile(rule string) (*regexp.Regexp, error) {
 	return regexp.Compile(string(runes))
 }
 
-func get_filtered(obj, root interface{}, filter string) ([]interface{}, error) {
+// filterPredicate is a parsed filter expression that can be evaluated
+// against individual candidates.
+type filterPredicate struct {
+	lp, op, rp string
+	pat        *regexp.Regexp
+}
+
+func newFilterPredicate(filter string) (*filterPredicate, error) {
 	lp, op, rp, err := parse_filter(filter)
 	if err != nil {
 		return nil, err
 	}
+	p := &filterPredicate{lp: lp, op: op, rp: rp}
+	if op == "=~" {
+		// regexp
+		p.pat, err = regFilterCompile(rp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *filterPredicate) match(obj, root interface{}) (bool, error) {
+	if p.pat != nil {
+		return eval_reg_filter(obj, root, p.lp, p.pat)
+	}
+	return eval_filter(obj, root, p.lp, p.op, p.rp)
+}
+
+// matchOperands is match also returning the error of resolving an operand,
+// which makes p false rather than failing the filter.
+func (p *filterPredicate) matchOperands(obj, root interface{}) (ok bool, missing, err error) {
+	if p.pat != nil {
+		// eval_reg_filter returns its errors
+		ok, err = eval_reg_filter(obj, root, p.lp, p.pat)
+		return ok, nil, err
+	}
+	return eval_filter_operands(obj, root, p.lp, p.op, p.rp)
+}
+
+// matchElem evaluates pred on obj, the element i of the filtered value.
+func (ev *evaluator) matchElem(pred *filterPredicate, i int, obj, root interface{}) (bool, error) {
+	if ev == nil || ev.filtered == nil {
+		return pred.match(obj, root)
+	}
+	return ev.traceMatch(pred, i, obj, root)
+}
+
+// matchMember evaluates pred on obj, the member name of the filtered value.
+func (ev *evaluator) matchMember(pred *filterPredicate, name string, obj, root interface{}) (bool, error) {
+	if ev == nil || ev.filtered == nil {
+		return pred.match(obj, root)
+	}
+	return ev.traceMatch(pred, name, obj, root)
+}
+
+// traceMatch evaluates pred on obj and reports the result to ev.filtered.
+func (ev *evaluator) traceMatch(pred *filterPredicate, seg, obj, root interface{}) (bool, error) {
+	ok, missing, err := pred.matchOperands(obj, root)
+	if err != nil {
+		ev.filtered(seg, ok, err)
+	} else {
+		ev.filtered(seg, ok, missing)
+	}
+	return ok, err
+}
+
+func get_filtered(ev *evaluator, obj, root interface{}, filter string) ([]interface{}, error) {
+	t, err := lookupFiltered(ev, tracedValue{value: obj}, root, filter)
+	if err != nil {
+		return nil, err
+	}
+	return t.value.([]interface{}), nil
+}
 
-	res := []interface{}{}
+// lookupFiltered is get_filtered on traced values. It selects the elements
+// of arrays and the members of maps in key order.
+func lookupFiltered(ev *evaluator, t tracedValue, root interface{}, filter string) (tracedValue, error) {
+	pred, err := newFilterPredicate(filter)
+	if err != nil {
+		return tracedValue{}, err
+	}
+	obj := t.value
+	if reflect.TypeOf(obj) == nil {
+		return tracedValue{}, ErrGetFromNullObj
+	}
+	res := newTracedList(t, []interface{}{})
 
 	switch reflect.TypeOf(obj).Kind() {
-	case reflect.Slice:
-		if op == "=~" {
-			// regexp
-			pat, err := regFilterCompile(rp)
-			if err != nil {
-				return nil, err
+	case reflect.Slice, reflect.Array:
+		v := reflect.ValueOf(obj)
+		for i := 0; i < v.Len(); i++ {
+			if err := ev.tick(); err != nil {
+				return tracedValue{}, err
 			}
-
-			for i := 0; i < reflect.ValueOf(obj).Len(); i++ {
-				tmp := reflect.ValueOf(obj).Index(i).Interface()
-				ok, err := eval_reg_filter(tmp, root, lp, pat)
-				if err != nil {
-					return nil, err
-				}
-				if ok == true {
-					res = append(res, tmp)
-				}
+			tmp := t.elem(v, i)
+			ok, err := ev.matchElem(pred, i, tmp.value, root)
+			if err != nil {
+				return tracedValue{}, err
 			}
-		} else {
-			for i := 0; i < reflect.ValueOf(obj).Len(); i++ {
-				tmp := reflect.ValueOf(obj).Index(i).Interface()
-				ok, err := eval_filter(tmp, root, lp, op, rp)
-				if err != nil {
-					return nil, err
-				}
-				if ok == true {
-					res = append(res, tmp)
+			if ok == true {
+				if err := ev.result(len(res.values) + 1); err != nil {
+					return tracedValue{}, err
 				}
+				res.add(tmp)
 			}
 		}
-		return res, nil
 	case reflect.Map:
-		if op == "=~" {
-			// regexp
-			pat, err := regFilterCompile(rp)
+		v := reflect.ValueOf(obj)
+		keys, names := sortedMapKeys(v)
+		for i, kv := range keys {
+			if err := ev.tick(); err != nil {
+				return tracedValue{}, err
+			}
+			tmp := t.member(names[i], v.MapIndex(kv).Interface())
+			ok, err := ev.matchMember(pred, names[i], tmp.value, root)
 			if err != nil {
-				return nil, err
-			}
-
-			for _, kv := range reflect.ValueOf(obj).MapKeys() {
-				tmp := reflect.ValueOf(obj).MapIndex(kv).Interface()
-				ok, err := eval_reg_filter(tmp, root, lp, pat)
-				if err != nil {
-					return nil, err
-				}
-				if ok == true {
-					res = append(res, tmp)
-				}
+				return tracedValue{}, err
 			}
-		} else {
-			for _, kv := range reflect.ValueOf(obj).MapKeys() {
-				tmp := reflect.ValueOf(obj).MapIndex(kv).Interface()
-				ok, err := eval_filter(tmp, root, lp, op, rp)
-				if err != nil {
-					return nil, err
-				}
-				if ok == true {
-					res = append(res, tmp)
+			if ok == true {
+				if err := ev.result(len(res.values) + 1); err != nil {
+					return tracedValue{}, err
 				}
+				res.add(tmp)
 			}
 		}
 	default:
-		return nil, fmt.Errorf("don't support filter on this type: %v", reflect.TypeOf(obj).Kind())
+		return tracedValue{}, fmt.Errorf("don't support filter on this type: %v", reflect.TypeOf(obj).Kind())
 	}
 
-	return res, nil
+	return res.result(), nil
 }
 
-func get_scan(obj interface{}) (interface{}, error) {
+func get_scan(ev *evaluator, obj interface{}) (interface{}, error) {
+	return scan(ev, obj, map[visitKey]bool{})
+}
+
+func scan(ev *evaluator, obj interface{}, onPath map[visitKey]bool) (interface{}, error) {
 	if reflect.TypeOf(obj) == nil {
 		return nil, nil
 	}
@@ -716,28 +1071,49 @@ func get_scan(obj interface{}) (interface{}, error) {
 				return sortedKeys[i] < sortedKeys[j]
 			})
 			for _, k := range sortedKeys {
+				if err := ev.tick(); err != nil {
+					return nil, err
+				}
 				res = append(res, jsonMap[k])
 			}
 			return res, nil
 		}
-		keys := reflect.ValueOf(obj).MapKeys()
+		type namedKey struct {
+			name string
+			key  reflect.Value
+		}
+		keys := []namedKey{}
+		for _, k := range reflect.ValueOf(obj).MapKeys() {
+			name, _ := mapKeyName(k)
+			keys = append(keys, namedKey{name, k})
+		}
 		sort.Slice(keys, func(i, j int) bool {
-			ki, kj := keys[i].String(), keys[j].String()
+			ki, kj := keys[i].name, keys[j].name
 			if len(ki) != len(kj) {
 				return len(ki) < len(kj)
 			}
 			return ki < kj
 		})
 		for _, k := range keys {
-			res = append(res, reflect.ValueOf(obj).MapIndex(k).Interface())
+			if err := ev.tick(); err != nil {
+				return nil, err
+			}
+			res = append(res, reflect.ValueOf(obj).MapIndex(k.key).Interface())
 		}
 		return res, nil
 	case reflect.Slice:
 		// slice we should get from all objects in it.
 		var res []interface{}
+		if key, ok := refKey(reflect.ValueOf(obj)); ok {
+			if onPath[key] {
+				return res, ev.cycle()
+			}
+			onPath[key] = true
+			defer delete(onPath, key)
+		}
 		for i := 0; i < reflect.ValueOf(obj).Len(); i++ {
 			tmp := reflect.ValueOf(obj).Index(i).Interface()
-			newObj, err := get_scan(tmp)
+			newObj, err := scan(ev, tmp, onPath)
 			if err != nil {
 				return nil, err
 			}
@@ -910,6 +1286,29 @@ func get_lp_v(obj, root interface{}, lp string) (interface{}, error) {
 
 // eval_filter_func evaluates function calls in filter expressions
 func eval_filter_func(obj, root interface{}, expr string) (interface{}, error) {
+	funcName, args, err := parse_func_call(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Evaluate function based on name
+	switch funcName {
+	case "count":
+		return eval_count(obj, root, args)
+	case "match":
+		return eval_match(obj, root, args)
+	case "search":
+		return eval_search(obj, root, args)
+	case "length":
+		return eval_length(obj, root, args)
+	default:
+		return nil, fmt.Errorf("unsupported function: %s()", funcName)
+	}
+}
+
+// parse_func_call splits a function call like match(@.a, 'x') into its
+// name and arguments, respecting nested parentheses and quotes.
+func parse_func_call(expr string) (funcName string, args []string, err error) {
 	// Find the first ( that starts the function arguments
 	parenIdx := -1
 	for i, c := range expr {
@@ -920,10 +1319,10 @@ func eval_filter_func(obj, root interface{}, expr string) (interface{}, error) {
 	}
 
 	if parenIdx < 0 {
-		return nil, fmt.Errorf("invalid function call: %s", expr)
+		return "", nil, fmt.Errorf("invalid function call: %s", expr)
 	}
 
-	funcName := strings.TrimSpace(expr[:parenIdx])
+	funcName = strings.TrimSpace(expr[:parenIdx])
 
 	// Find the matching closing parenthesis
 	argsStart := parenIdx + 1
@@ -942,13 +1341,12 @@ func eval_filter_func(obj, root interface{}, expr string) (interface{}, error) {
 	}
 
 	if argsEnd < 0 {
-		return nil, fmt.Errorf("mismatched parentheses in function call: %s", expr)
+		return "", nil, fmt.Errorf("mismatched parentheses in function call: %s", expr)
 	}
 
 	argsStr := expr[argsStart:argsEnd]
 
 	// Split arguments by comma (respecting nested parentheses and quotes)
-	var args []string
 	current := ""
 	argDepth := 0
 	quoteChar := rune(0)
@@ -979,20 +1377,7 @@ func eval_filter_func(obj, root interface{}, expr string) (interface{}, error) {
 	if current != "" {
 		args = append(args, strings.TrimSpace(current))
 	}
-
-	// Evaluate function based on name
-	switch funcName {
-	case "count":
-		return eval_count(obj, root, args)
-	case "match":
-		return eval_match(obj, root, args)
-	case "search":
-		return eval_search(obj, root, args)
-	case "length":
-		return eval_length(obj, root, args)
-	default:
-		return nil, fmt.Errorf("unsupported function: %s()", funcName)
-	}
+	return funcName, args, nil
 }
 
 // eval_count evaluates count() function - returns the count of nodes in a nodelist
@@ -1147,7 +1532,14 @@ func eval_length(obj, root interface{}, args []string) (interface{}, error) {
 }
 
 func eval_filter(obj, root interface{}, lp, op, rp string) (res bool, err error) {
-	lp_v, err := get_lp_v(obj, root, lp)
+	res, _, err = eval_filter_operands(obj, root, lp, op, rp)
+	return res, err
+}
+
+// eval_filter_operands is eval_filter also returning the error of resolving
+// an operand, which eval_filter takes as a missing value.
+func eval_filter_operands(obj, root interface{}, lp, op, rp string) (res bool, missing, err error) {
+	lp_v, missing := get_lp_v(obj, root, lp)
 
 	// If op is empty, treat it as an exists check (truthy check)
 	if op == "" {
@@ -1162,18 +1554,20 @@ func eval_filter(obj, root interface{}, lp, op, rp string) (res bool, err error)
 			// and returned the result (which could be bool, int, etc.)
 			switch v := lp_v.(type) {
 			case bool:
-				return v, nil
-			case int, int8, int16, int32, int64, float32, float64:
+				return v, missing, nil
+			case int, int8, int16, int32, int64, float32, float64,
+				json.Number, *big.Int, *big.Float:
 				// Non-zero values are truthy
-				return v != 0, nil
+				r, ok := numberRat(v)
+				return ok && r.Sign() != 0, missing, nil
 			default:
 				// For other types, check if not nil
-				return lp_v != nil, nil
+				return lp_v != nil, missing, nil
 			}
 		}
-		return lp_v != nil, nil
+		return lp_v != nil, missing, nil
 	} else if op == "=~" {
-		return false, fmt.Errorf("not implemented yet")
+		return false, missing, fmt.Errorf("not implemented yet")
 	} else {
 		var rp_v interface{}
 		if strings.HasPrefix(rp, "@.") {
@@ -1183,8 +1577,12 @@ func eval_filter(obj, root interface{}, lp, op, rp string) (res bool, err error)
 		} else {
 			rp_v = rp
 		}
+		if missing == nil {
+			missing = err
+		}
 		//fmt.Printf("lp_v: %v, rp_v: %v\n", lp_v, rp_v)
-		return cmp_any(lp_v, rp_v, op)
+		res, err = cmp_any(lp_v, rp_v, op)
+		return res, missing, err
 	}
 }
 
@@ -1210,6 +1608,9 @@ func get_length(obj interface{}) (interface{}, error) {
 		return len(v), nil
 	case map[string]interface{}:
 		return len(v), nil
+	case json.Number:
+		// a number, even though it is stored as a string
+		return nil, fmt.Errorf("length() not supported for type: %T", obj)
 	default:
 		// Try to use reflection for other types
 		rv := reflect.ValueOf(obj)
@@ -1223,22 +1624,80 @@ func get_length(obj interface{}) (interface{}, error) {
 }
 
 func isNumber(o interface{}) bool {
+	_, ok := numberRat(o)
+	return ok
+}
+
+// numberRat converts a numeric value to an exact rational number. Besides
+// Go numeric kinds it understands json.Number, *big.Int, *big.Float,
+// *big.Rat and numeric strings. Floats go through their shortest decimal
+// form so that the float64 8.95 equals the literal 8.95.
+func numberRat(o interface{}) (*big.Rat, bool) {
 	switch v := o.(type) {
-	case int, int8, int16, int32, int64:
-		return true
-	case uint, uint8, uint16, uint32, uint64:
-		return true
-	case float32, float64:
-		return true
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int8:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int16:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case uint:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(uint64(v))), true
+	case uint8:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case uint16:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case uint32:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(v)), true
+	case float32:
+		return floatRat(float64(v), 32)
+	case float64:
+		return floatRat(v, 64)
+	case json.Number:
+		return stringRat(string(v))
+	case *big.Int:
+		if v == nil {
+			return nil, false
+		}
+		return new(big.Rat).SetInt(v), true
+	case *big.Float:
+		if v == nil || v.IsInf() {
+			return nil, false
+		}
+		r, _ := v.Rat(nil)
+		return r, true
+	case *big.Rat:
+		if v == nil {
+			return nil, false
+		}
+		return v, true
 	case string:
-		_, err := strconv.ParseFloat(v, 64)
-		if err == nil {
-			return true
-		} else {
-			return false
+		return stringRat(v)
+	}
+	return nil, false
+}
+
+func floatRat(f float64, bitSize int) (*big.Rat, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+func stringRat(s string) (*big.Rat, bool) {
+	// only accept what strconv accepts as a float, big.Rat also parses "1/3".
+	// Out of range literals such as 1e400 are still numbers here.
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		if ne, ok := err.(*strconv.NumError); !ok || ne.Err != strconv.ErrRange {
+			return nil, false
 		}
 	}
-	return false
+	return new(big.Rat).SetString(s)
 }
 
 func cmp_any(obj1, obj2 interface{}, op string) (bool, error) {
@@ -1248,12 +1707,26 @@ func cmp_any(obj1, obj2 interface{}, op string) (bool, error) {
 		return false, fmt.Errorf("op should only be <, <=, ==, >= and >")
 	}
 
-	var exp string
-	if isNumber(obj1) && isNumber(obj2) {
-		exp = fmt.Sprintf(`%v %s %v`, obj1, op, obj2)
-	} else {
-		exp = fmt.Sprintf(`"%v" %s "%v"`, obj1, op, obj2)
+	if r1, ok := numberRat(obj1); ok {
+		if r2, ok := numberRat(obj2); ok {
+			// exact comparison, integers of any size never lose precision
+			c := r1.Cmp(r2)
+			switch op {
+			case "<":
+				return c < 0, nil
+			case "<=":
+				return c <= 0, nil
+			case "==":
+				return c == 0, nil
+			case ">=":
+				return c >= 0, nil
+			default:
+				return c > 0, nil
+			}
+		}
 	}
+
+	exp := fmt.Sprintf(`"%v" %s "%v"`, obj1, op, obj2)
 	//fmt.Println("exp: ", exp)
 	fset := token.NewFileSet()
 	res, err := types.Eval(fset, nil, 0, exp)
@@ -1270,38 +1743,109 @@ func cmp_any(obj1, obj2 interface{}, op string) (bool, error) {
 	return false, nil
 }
 
-func getAllDescendants(obj interface{}) []interface{} {
-	res := []interface{}{}
-	var recurse func(curr interface{})
-	recurse = func(curr interface{}) {
-		res = append(res, curr)
-		v := reflect.ValueOf(curr)
+func getAllDescendants(ev *evaluator, obj interface{}) ([]interface{}, error) {
+	t, err := lookupDescendants(ev, tracedValue{value: obj})
+	if err != nil {
+		return nil, err
+	}
+	return t.value.([]interface{}), nil
+}
+
+// lookupDescendants is getAllDescendants on traced values.
+func lookupDescendants(ev *evaluator, t tracedValue) (tracedValue, error) {
+	res := newTracedList(t, []interface{}{})
+	onPath := map[visitKey]bool{}
+	var recurse func(curr tracedValue) error
+	recurse = func(curr tracedValue) error {
+		if err := ev.tick(); err != nil {
+			return err
+		}
+		if err := ev.enter(); err != nil {
+			return err
+		}
+		defer ev.leave()
+		v := reflect.ValueOf(curr.value)
+		if key, ok := refKey(v); ok {
+			if onPath[key] {
+				return ev.cycle()
+			}
+			onPath[key] = true
+			defer delete(onPath, key)
+		}
+		if err := ev.result(len(res.values) + 1); err != nil {
+			return err
+		}
+		res.add(curr)
 		if !v.IsValid() {
-			return
+			return nil
 		}
 
 		kind := v.Kind()
 		if kind == reflect.Ptr {
 			v = v.Elem()
 			if !v.IsValid() {
-				return
+				return nil
 			}
 			kind = v.Kind()
 		}
 
 		switch kind {
 		case reflect.Map:
-			for _, k := range v.MapKeys() {
-				recurse(v.MapIndex(k).Interface())
+			// visit members in key order so results are deterministic
+			keys, names := sortedMapKeys(v)
+			for i, k := range keys {
+				if err := recurse(curr.member(names[i], v.MapIndex(k).Interface())); err != nil {
+					return err
+				}
 			}
 		case reflect.Slice, reflect.Array:
 			for i := 0; i < v.Len(); i++ {
-				recurse(v.Index(i).Interface())
+				if err := recurse(curr.elem(v, i)); err != nil {
+					return err
+				}
+			}
+		case reflect.Struct:
+			for _, f := range structFields(v.Type()) {
+				fv, ok := fieldByIndex(v, f.index)
+				if !ok {
+					continue
+				}
+				if err := recurse(curr.member(f.name, fv.Interface())); err != nil {
+					return err
+				}
 			}
 		}
+		return nil
 	}
-	recurse(obj)
-	return res
+	if err := recurse(t); err != nil {
+		return tracedValue{}, err
+	}
+	return res.result(), nil
+}
+
+// sortedMapKeys returns the keys of the map v and their names, sorted by
+// name.
+func sortedMapKeys(v reflect.Value) ([]reflect.Value, []string) {
+	keys := v.MapKeys()
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i], _ = mapKeyName(k)
+	}
+	sort.Sort(byName{keys, names})
+	return keys, names
+}
+
+// byName sorts map keys by their names.
+type byName struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (b byName) Len() int           { return len(b.keys) }
+func (b byName) Less(i, j int) bool { return b.names[i] < b.names[j] }
+func (b byName) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.names[i], b.names[j] = b.names[j], b.names[i]
 }
 
 // ============================================================================
@@ -1311,7 +1855,7 @@ func getAllDescendants(obj interface{}) []interface{} {
 // JsonPathSet sets a value at the specified JSONPath and returns a new object
 // (deep copy approach - original object is not modified)
 func JsonPathSet(obj interface{}, jpath string, value interface{}) (interface{}, error) {
-	c, err := Compile(jpath)
+	c, err := compileDefault(jpath)
 	if err != nil {
 		return nil, err
 	}
@@ -1320,44 +1864,100 @@ func JsonPathSet(obj interface{}, jpath string, value interface{}) (interface{},
 
 // Set sets a value at the compiled path and returns a new object
 func (c *Compiled) Set(obj interface{}, value interface{}) (interface{}, error) {
-	// Deep copy the object first
-	copiedObj := deepCopy(obj)
+	return c.set(c.newEvaluator(nil), obj, value)
+}
+
+// SetContext is like Set but stops with ctx.Err() once ctx is done while
+// copying the object.
+func (c *Compiled) SetContext(ctx context.Context, obj interface{}, value interface{}) (interface{}, error) {
+	return c.set(c.newEvaluator(ctx), obj, value)
+}
 
+func (c *Compiled) set(ev *evaluator, obj interface{}, value interface{}) (interface{}, error) {
 	// Check if path is valid
 	if len(c.steps) == 0 {
 		return nil, fmt.Errorf("empty path")
 	}
 
+	// Deep copy the object first, the set functions update it in place
+	if c.mode != SetInPlace {
+		var err error
+		obj, err = newCopier(ev).object(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Navigate to parent and set the value
-	result, err := set_recursive(copiedObj, c.steps, 0, value)
+	result, err := set_recursive(ev, obj, c.steps, 0, value)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-// set_recursive recursively navigates to the target location and sets the value
-func set_recursive(obj interface{}, steps []step, idx int, value interface{}) (interface{}, error) {
+// set_recursive updates obj at steps[idx:] and returns it. Maps, slices and
+// pointees are updated in place, other values are returned updated.
+func set_recursive(ev *evaluator, obj interface{}, steps []step, idx int, value interface{}) (interface{}, error) {
 	if idx >= len(steps) {
 		return value, nil
 	}
+	if err := ev.tick(); err != nil {
+		return nil, err
+	}
 
 	step := steps[idx]
+	if obj == nil && ev.creates() {
+		obj = newContainer(step)
+	}
+
+	// Traverse pointers, the pointee is updated
+	if v := reflect.ValueOf(obj); v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if !ev.creates() {
+				return nil, ErrGetFromNullObj
+			}
+			v = reflect.New(v.Type().Elem())
+			obj = v.Interface()
+		}
+		newVal, err := set_recursive(ev, v.Elem().Interface(), steps, idx, value)
+		if err != nil {
+			return nil, err
+		}
+		converted, err := convertValue(newVal, v.Type().Elem())
+		if err != nil {
+			return nil, err
+		}
+		v.Elem().Set(converted)
+		return obj, nil
+	}
 
 	switch step.op {
 	case "key":
-		return set_key(obj, step.key, steps, idx, value)
+		return set_key(ev, obj, step.key, steps, idx, value)
+	case "member":
+		s, err := memberStep(obj, step)
+		if err != nil {
+			return nil, err
+		}
+		if s.op == "key" {
+			return set_key(ev, obj, s.key, steps, idx, value)
+		}
+		return set_idx(ev, obj, s, steps, idx, value)
 	case "idx":
-		return set_idx(obj, step, steps, idx, value)
+		return set_idx(ev, obj, step, steps, idx, value)
 	case "range":
-		return set_range(obj, step, steps, idx, value)
+		if len(step.key) > 0 {
+			return set_keyed(ev, obj, step, steps, idx, value)
+		}
+		return set_range(ev, obj, step, steps, idx, value)
 	default:
 		return nil, fmt.Errorf("unsupported operation for set: %s", step.op)
 	}
 }
 
 // set_key sets a value by key in a map or struct
-func set_key(obj interface{}, key string, steps []step, idx int, value interface{}) (interface{}, error) {
+func set_key(ev *evaluator, obj interface{}, key string, steps []step, idx int, value interface{}) (interface{}, error) {
 	if obj == nil {
 		return nil, ErrGetFromNullObj
 	}
@@ -1379,72 +1979,93 @@ func set_key(obj interface{}, key string, steps []step, idx int, value interface
 	case reflect.Map:
 		// Check if map is nil
 		if v.IsNil() {
-			return nil, ErrGetFromNullObj
+			if !ev.creates() {
+				return nil, ErrGetFromNullObj
+			}
+			v = reflect.MakeMap(v.Type())
 		}
 
-		// Deep copy the map
-		newMap := reflect.MakeMap(v.Type())
-		for _, mapKey := range v.MapKeys() {
-			newMap.SetMapIndex(mapKey, deepCopyValue(v.MapIndex(mapKey)))
+		mapKey, err := editMapKey(v, key)
+		if err != nil {
+			return nil, err
 		}
+		name := key
+		if n, err := mapKeyName(mapKey); err == nil {
+			name = n
+		}
+		currentVal := v.MapIndex(mapKey)
 
-		// Navigate to next level or set value
-		if idx+1 >= len(steps) {
-			// This is the final key - set the value
-			mapKey := reflect.ValueOf(key)
-			newMap.SetMapIndex(mapKey, reflect.ValueOf(value))
-		} else {
-			// Navigate deeper
-			mapKey := reflect.ValueOf(key)
-			currentVal := v.MapIndex(mapKey)
+		if idx+1 >= len(steps) && ev.deletes() {
 			if !currentVal.IsValid() {
-				return nil, fmt.Errorf("key error: %s not found in object", key)
+				return nil, notFound("key error: %s not found in object", key)
 			}
-			newVal, err := set_recursive(deepCopyValue(currentVal).Interface(), steps, idx+1, value)
+			v.SetMapIndex(mapKey, reflect.Value{})
+			ev.record("remove", name, nil)
+			return v.Interface(), nil
+		}
+
+		// Navigate to next level or set value
+		newVal := value
+		if idx+1 < len(steps) {
+			var current interface{}
+			if currentVal.IsValid() {
+				current = currentVal.Interface()
+			} else if !ev.creates() {
+				return nil, notFound("key error: %s not found in object", key)
+			}
+			ev.push(name)
+			newVal, err = set_recursive(ev, current, steps, idx+1, value)
+			ev.pop()
 			if err != nil {
 				return nil, err
 			}
-			newMap.SetMapIndex(mapKey, reflect.ValueOf(newVal))
 		}
-		return newMap.Interface(), nil
-
-	case reflect.Struct:
-		// Find the field by name or json tag
-		fieldIdx := -1
-		for i := 0; i < v.NumField(); i++ {
-			field := v.Type().Field(i)
-			if field.Name == key {
-				fieldIdx = i
-				break
-			}
-			if tag := field.Tag.Get("json"); tag != "" {
-				if tag == key || strings.HasPrefix(tag, key+",") {
-					fieldIdx = i
-					break
-				}
+		converted, err := convertValue(newVal, v.Type().Elem())
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %v", key, err)
+		}
+		v.SetMapIndex(mapKey, converted)
+		if idx+1 >= len(steps) {
+			if currentVal.IsValid() {
+				ev.record("replace", name, converted.Interface())
+			} else {
+				ev.record("add", name, converted.Interface())
 			}
 		}
+		return v.Interface(), nil
 
-		if fieldIdx < 0 {
-			return nil, fmt.Errorf("key error: %s not found in struct", key)
+	case reflect.Struct:
+		// Find the field the same way get_key does
+		f, ok := lookupField(v.Type(), key)
+		if !ok {
+			return nil, notFound("key error: %s not found in struct", key)
 		}
 
 		// Create a copy of the struct
 		newStruct := reflect.New(v.Type()).Elem()
-		for i := 0; i < v.NumField(); i++ {
-			if i == fieldIdx {
-				if idx+1 >= len(steps) {
-					newStruct.Field(i).Set(reflect.ValueOf(value))
-				} else {
-					newVal, err := set_recursive(deepCopyValue(v.Field(i)).Interface(), steps, idx+1, value)
-					if err != nil {
-						return nil, err
-					}
-					newStruct.Field(i).Set(reflect.ValueOf(newVal))
-				}
-			} else {
-				newStruct.Field(i).Set(v.Field(i))
+		newStruct.Set(v)
+		field, err := settableFieldByIndex(newStruct, f.index, ev.inPlace())
+		if err != nil {
+			return nil, err
+		}
+		newVal := value
+		if idx+1 < len(steps) {
+			ev.push(f.name)
+			newVal, err = set_recursive(ev, field.Interface(), steps, idx+1, value)
+			ev.pop()
+			if err != nil {
+				return nil, err
 			}
+		} else if ev.deletes() {
+			return nil, fmt.Errorf("cannot delete struct field %s", f.name)
+		}
+		converted, err := convertValue(newVal, field.Type())
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", key, err)
+		}
+		field.Set(converted)
+		if idx+1 >= len(steps) {
+			ev.record("replace", f.name, converted.Interface())
 		}
 		return newStruct.Interface(), nil
 
@@ -1453,106 +2074,109 @@ func set_key(obj interface{}, key string, steps []step, idx int, value interface
 	}
 }
 
+// set_child sets key of the map or struct obj to value, an already updated
+// child, so the write is neither recorded nor a deletion.
+func set_child(ev *evaluator, obj interface{}, key string, value interface{}) (interface{}, error) {
+	if ev != nil {
+		patch, deleting := ev.patch, ev.deleting
+		ev.patch, ev.deleting = nil, false
+		defer func() { ev.patch, ev.deleting = patch, deleting }()
+	}
+	return set_key(ev, obj, key, []step{{op: "key", key: key}}, 0, value)
+}
+
+// set_keyed handles an idx or range step with a key, e.g. $.numbers[0]: it
+// updates the child at key and sets it back into obj.
+func set_keyed(ev *evaluator, obj interface{}, step step, steps []step, idx int, value interface{}) (interface{}, error) {
+	child, err := get_key(obj, step.key)
+	if err != nil {
+		if !ev.creates() || !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		child = nil
+	}
+	indexStep := step
+	indexStep.key = ""
+	rest := append(steps[:0:0], indexStep)
+	if idx+1 < len(steps) {
+		rest = append(rest, steps[idx+1:]...)
+	}
+	ev.push(childName(obj, step.key))
+	newChild, err := set_recursive(ev, child, rest, 0, value)
+	ev.pop()
+	if err != nil {
+		return nil, err
+	}
+	return set_child(ev, obj, step.key, newChild)
+}
+
 // set_idx sets a value by index in a slice
-func set_idx(obj interface{}, step step, steps []step, idx int, value interface{}) (interface{}, error) {
+func set_idx(ev *evaluator, obj interface{}, step step, steps []step, idx int, value interface{}) (interface{}, error) {
 	if obj == nil {
 		return nil, ErrGetFromNullObj
 	}
 
-	// Store original obj if we need to update a map later
-	var originalObj interface{}
-	var needToUpdateMap bool
-
 	// First, handle key if present (e.g., $.numbers[0] where key="numbers")
 	if len(step.key) > 0 {
-		var err error
-		originalObj = obj
-		needToUpdateMap = true
-		obj, err = get_key(obj, step.key)
-		if err != nil {
-			return nil, err
-		}
+		return set_keyed(ev, obj, step, steps, idx, value)
 	}
 
-	v := reflect.ValueOf(obj)
-	// Unwrap interface to get the underlying value
-	if v.Kind() == reflect.Interface {
-		v = v.Elem()
-		if !v.IsValid() {
-			return nil, ErrGetFromNullObj
-		}
-	}
-	if v.Kind() != reflect.Slice {
-		return nil, fmt.Errorf("cannot index non-slice type: %s", v.Kind())
+	v, err := settableSlice(obj)
+	if err != nil {
+		return nil, fmt.Errorf("cannot index %w", err)
 	}
 
-	// Get the index to set
+	// Get the indices to set
 	indices := step.args.([]int)
 	if len(indices) == 0 {
 		return nil, fmt.Errorf("cannot index on empty slice")
 	}
 
-	// For now, only support single index in set operations
-	targetIdx := indices[0]
+	// Handle negative indices, each element once
 	length := v.Len()
-
-	// Handle negative index
-	if targetIdx < 0 {
-		targetIdx = length + targetIdx
+	targets := make([]int, 0, len(indices))
+	seen := map[int]bool{}
+	last := -1
+	for _, i := range indices {
+		if i < 0 {
+			i = length + i
+		}
+		if i < 0 {
+			return nil, notFound("index out of range: len: %v, idx: %v", length, i)
+		}
+		if !seen[i] {
+			seen[i] = true
+			targets = append(targets, i)
+		}
+		if i > last {
+			last = i
+		}
 	}
 
-	if targetIdx < 0 || targetIdx >= length {
-		return nil, fmt.Errorf("index out of range: len: %v, idx: %v", length, targetIdx)
+	if last >= length && ev.creates() && v.Kind() == reflect.Slice {
+		// grow the slice with zero values up to the last index
+		v = reflect.AppendSlice(v, reflect.MakeSlice(v.Type(), last+1-length, last+1-length))
+		length = v.Len()
 	}
-
-	// Create a new slice with copied elements
-	newSlice := reflect.MakeSlice(v.Type(), length, length)
-	for i := 0; i < length; i++ {
-		if i == targetIdx {
-			if idx+1 >= len(steps) {
-				// This is the final index - set the value
-				newSlice.Index(i).Set(reflect.ValueOf(value))
-			} else {
-				// Navigate deeper
-				newVal, err := set_recursive(deepCopyValue(v.Index(i)).Interface(), steps, idx+1, value)
-				if err != nil {
-					return nil, err
-				}
-				newSlice.Index(i).Set(reflect.ValueOf(newVal))
-			}
-		} else {
-			newSlice.Index(i).Set(v.Index(i))
-		}
+	if last >= length {
+		return nil, notFound("index out of range: len: %v, idx: %v", length, last)
 	}
 
-	// If we had a key, we need to set the modified slice back to the original map
-	if needToUpdateMap {
-		// Get the type of the original map
-		origV := reflect.ValueOf(originalObj)
-		if origV.Kind() == reflect.Interface {
-			origV = origV.Elem()
-		}
-		if origV.Kind() != reflect.Map {
-			return nil, fmt.Errorf("expected map when key is present, got %s", origV.Kind())
-		}
+	if idx+1 >= len(steps) && ev.deletes() {
+		return delete_indices(ev, v, targets)
+	}
 
-		// Create a new map with all keys copied and the target key updated
-		newMap := reflect.MakeMap(origV.Type())
-		for _, mapKey := range origV.MapKeys() {
-			if mapKey.String() == step.key {
-				newMap.SetMapIndex(mapKey, reflect.ValueOf(newSlice.Interface()))
-			} else {
-				newMap.SetMapIndex(mapKey, deepCopyValue(origV.MapIndex(mapKey)))
-			}
+	for _, i := range targets {
+		if err := set_element(ev, v, i, steps, idx, value); err != nil {
+			return nil, err
 		}
-		return newMap.Interface(), nil
 	}
 
-	return newSlice.Interface(), nil
+	return v.Interface(), nil
 }
 
 // set_range sets values in a range in a slice
-func set_range(obj interface{}, step step, steps []step, idx int, value interface{}) (interface{}, error) {
+func set_range(ev *evaluator, obj interface{}, step step, steps []step, idx int, value interface{}) (interface{}, error) {
 	if obj == nil {
 		return nil, ErrGetFromNullObj
 	}
@@ -1566,16 +2190,9 @@ func set_range(obj interface{}, step step, steps []step, idx int, value interfac
 		}
 	}
 
-	v := reflect.ValueOf(obj)
-	// Unwrap interface to get the underlying value
-	if v.Kind() == reflect.Interface {
-		v = v.Elem()
-		if !v.IsValid() {
-			return nil, ErrGetFromNullObj
-		}
-	}
-	if v.Kind() != reflect.Slice {
-		return nil, fmt.Errorf("cannot apply range on non-slice type: %s", v.Kind())
+	v, err := settableSlice(obj)
+	if err != nil {
+		return nil, fmt.Errorf("cannot apply range on %w", err)
 	}
 
 	args := step.args.([2]interface{})
@@ -1610,102 +2227,257 @@ func set_range(obj interface{}, step step, steps []step, idx int, value interfac
 		from = to
 	}
 
-	// Create a new slice with copied elements
-	newSlice := reflect.MakeSlice(v.Type(), length, length)
-	for i := 0; i < length; i++ {
-		if i >= from && i < to {
-			if idx+1 >= len(steps) {
-				// This is the final range - set the values
-				newSlice.Index(i).Set(reflect.ValueOf(value))
-			} else {
-				// Navigate deeper
-				newVal, err := set_recursive(deepCopyValue(v.Index(i)).Interface(), steps, idx+1, value)
-				if err != nil {
-					return nil, err
-				}
-				newSlice.Index(i).Set(reflect.ValueOf(newVal))
-			}
-		} else {
-			newSlice.Index(i).Set(v.Index(i))
+	if idx+1 >= len(steps) && ev.deletes() {
+		return delete_elements(ev, v, from, to)
+	}
+
+	for i := from; i < to; i++ {
+		if err := set_element(ev, v, i, steps, idx, value); err != nil {
+			return nil, err
 		}
 	}
 
-	return newSlice.Interface(), nil
+	return v.Interface(), nil
 }
 
-// deepCopy creates a deep copy of the given object
-func deepCopy(obj interface{}) interface{} {
-	if obj == nil {
-		return nil
-	}
-
+// settableSlice returns the slice obj, or an addressable copy of the array
+// obj. Slices and arrays behind a pointer are updated through it.
+func settableSlice(obj interface{}) (reflect.Value, error) {
 	v := reflect.ValueOf(obj)
-	if !v.IsValid() {
-		return nil
+	// Unwrap interface to get the underlying value
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+		if !v.IsValid() {
+			return reflect.Value{}, ErrGetFromNullObj
+		}
 	}
-
-	switch v.Kind() {
-	case reflect.Map:
+	if v.Kind() == reflect.Ptr {
 		if v.IsNil() {
-			return nil
-		}
-		newMap := reflect.MakeMap(v.Type())
-		for _, key := range v.MapKeys() {
-			newMap.SetMapIndex(key, deepCopyValue(v.MapIndex(key)))
+			return reflect.Value{}, ErrGetFromNullObj
 		}
-		return newMap.Interface()
-
+		v = v.Elem()
+	}
+	switch v.Kind() {
 	case reflect.Slice:
-		if v.IsNil() {
-			return nil
-		}
-		newSlice := reflect.MakeSlice(v.Type(), v.Len(), v.Cap())
-		for i := 0; i < v.Len(); i++ {
-			newSlice.Index(i).Set(deepCopyValue(v.Index(i)))
+		return v, nil
+	case reflect.Array:
+		if v.CanSet() {
+			return v, nil
 		}
-		return newSlice.Interface()
+		res := reflect.New(v.Type()).Elem()
+		res.Set(v)
+		return res, nil
+	}
+	return reflect.Value{}, fmt.Errorf("non-slice type: %s", v.Kind())
+}
 
-	case reflect.Ptr:
-		if v.IsNil() {
-			return nil
+// set_element sets element i of the slice or addressable array v.
+func set_element(ev *evaluator, v reflect.Value, i int, steps []step, idx int, value interface{}) error {
+	newVal := value
+	if idx+1 < len(steps) {
+		// Navigate deeper
+		var err error
+		ev.push(i)
+		newVal, err = set_recursive(ev, v.Index(i).Interface(), steps, idx+1, value)
+		ev.pop()
+		if err != nil {
+			return err
 		}
-		newPtr := reflect.New(v.Type().Elem())
-		copiedVal := deepCopyValue(v.Elem())
-		newPtr.Elem().Set(copiedVal)
-		return newPtr.Interface()
+	}
+	converted, err := convertValue(newVal, v.Type().Elem())
+	if err != nil {
+		return fmt.Errorf("index %d: %v", i, err)
+	}
+	v.Index(i).Set(converted)
+	if idx+1 >= len(steps) {
+		ev.record("replace", i, converted.Interface())
+	}
+	return nil
+}
 
-	case reflect.Struct:
-		newStruct := reflect.New(v.Type()).Elem()
-		for i := 0; i < v.NumField(); i++ {
-			newStruct.Field(i).Set(deepCopyValue(v.Field(i)))
-		}
-		return newStruct.Interface()
+// delete_elements returns a new slice without the elements [from, to) of v.
+func delete_elements(ev *evaluator, v reflect.Value, from, to int) (interface{}, error) {
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot delete from %s", v.Kind())
+	}
+	// later elements first, so the operations apply one after another
+	for i := to - 1; i >= from; i-- {
+		ev.record("remove", i, nil)
+	}
+	res := reflect.MakeSlice(v.Type(), 0, v.Len()-(to-from))
+	res = reflect.AppendSlice(res, v.Slice(0, from))
+	res = reflect.AppendSlice(res, v.Slice(to, v.Len()))
+	return res.Interface(), nil
+}
 
-	default:
-		// Primitive types - return as is
-		return obj
+// delete_indices returns a new slice without the elements of v at indices.
+func delete_indices(ev *evaluator, v reflect.Value, indices []int) (interface{}, error) {
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot delete from %s", v.Kind())
+	}
+	sorted := append([]int(nil), indices...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	// later elements first, so removals do not shift the ones to come
+	for _, i := range sorted {
+		ev.record("remove", i, nil)
 	}
+	res := reflect.MakeSlice(v.Type(), 0, v.Len()-len(sorted))
+	from := 0
+	for j := len(sorted) - 1; j >= 0; j-- {
+		res = reflect.AppendSlice(res, v.Slice(from, sorted[j]))
+		from = sorted[j] + 1
+	}
+	res = reflect.AppendSlice(res, v.Slice(from, v.Len()))
+	return res.Interface(), nil
+}
+
+// deepCopy creates a deep copy of the given object
+func deepCopy(obj interface{}) interface{} {
+	res, _ := newCopier(nil).object(obj)
+	return res
 }
 
 // deepCopyValue deep copies a reflect.Value and returns reflect.Value
 func deepCopyValue(v reflect.Value) reflect.Value {
+	res, _ := newCopier(nil).value(v)
+	return res
+}
+
+// copier deep copies values under the checks of an evaluator. Values that
+// are still being copied are remembered so that a reference back to one of
+// them reuses its copy instead of recursing forever.
+type copier struct {
+	ev       *evaluator
+	inFlight map[visitKey]reflect.Value
+}
+
+func newCopier(ev *evaluator) *copier {
+	return &copier{ev: ev, inFlight: map[visitKey]reflect.Value{}}
+}
+
+// object copies obj and returns the copy as interface{}
+func (cp *copier) object(obj interface{}) (interface{}, error) {
+	v := reflect.ValueOf(obj)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Map, reflect.Slice, reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+	}
+	copied, err := cp.value(v)
+	if err != nil || !copied.IsValid() {
+		return nil, err
+	}
+	return copied.Interface(), nil
+}
+
+// value copies v into a value of the same type
+func (cp *copier) value(v reflect.Value) (reflect.Value, error) {
 	if !v.IsValid() {
-		return reflect.ValueOf(nil)
+		return reflect.ValueOf(nil), nil
 	}
 
 	switch v.Kind() {
 	case reflect.Interface:
 		// Unwrap interface and deep copy the underlying value
 		if v.IsNil() {
-			return reflect.ValueOf(nil)
+			return reflect.Zero(v.Type()), nil
+		}
+		copied, err := cp.value(v.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		res := reflect.New(v.Type()).Elem()
+		res.Set(copied)
+		return res, nil
+
+	case reflect.Map, reflect.Slice, reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type()), nil
+		}
+	case reflect.Struct:
+	default:
+		// Primitive types - return as is
+		return v, nil
+	}
+
+	if err := cp.ev.tick(); err != nil {
+		return reflect.Value{}, err
+	}
+	if err := cp.ev.enter(); err != nil {
+		return reflect.Value{}, err
+	}
+	defer cp.ev.leave()
+
+	key, isRef := refKey(v)
+	if isRef {
+		if copied, ok := cp.inFlight[key]; ok {
+			if err := cp.ev.cycle(); err != nil {
+				return reflect.Value{}, err
+			}
+			return copied, nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		newMap := reflect.MakeMap(v.Type())
+		if isRef {
+			cp.inFlight[key] = newMap
+			defer delete(cp.inFlight, key)
+		}
+		for _, mapKey := range v.MapKeys() {
+			copied, err := cp.value(v.MapIndex(mapKey))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			newMap.SetMapIndex(mapKey, copied)
+		}
+		return newMap, nil
+
+	case reflect.Slice:
+		newSlice := reflect.MakeSlice(v.Type(), v.Len(), v.Cap())
+		if isRef {
+			cp.inFlight[key] = newSlice
+			defer delete(cp.inFlight, key)
+		}
+		for i := 0; i < v.Len(); i++ {
+			copied, err := cp.value(v.Index(i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			newSlice.Index(i).Set(copied)
 		}
-		return reflect.ValueOf(deepCopy(v.Elem().Interface()))
+		return newSlice, nil
 
-	case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Struct:
-		copied := deepCopy(v.Interface())
-		return reflect.ValueOf(copied)
+	case reflect.Ptr:
+		newPtr := reflect.New(v.Type().Elem())
+		cp.inFlight[key] = newPtr
+		defer delete(cp.inFlight, key)
+		copied, err := cp.value(v.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		newPtr.Elem().Set(copied)
+		return newPtr, nil
 
 	default:
-		return v
+		// unexported fields can not be set through reflection and keep
+		// sharing their values with the original
+		newStruct := reflect.New(v.Type()).Elem()
+		newStruct.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			copied, err := cp.value(v.Field(i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			newStruct.Field(i).Set(copied)
+		}
+		return newStruct, nil
 	}
 }
@@ -132,7 +132,7 @@ func Test_jsonpath_get_idx(t *testing.T) {
 func Test_jsonpath_get_range(t *testing.T) {
 	obj := []int{1, 2, 3, 4, 5}
 
-	res, err := get_range(obj, 0, 2)
+	res, err := get_range(nil, obj, 0, 2)
 	t.Logf("err: %v, res: %v", err, res)
 	if err != nil {
 		t.Errorf("failed to get_range: %v", err)
@@ -142,7 +142,7 @@ func Test_jsonpath_get_range(t *testing.T) {
 	}
 
 	obj1 := []interface{}{1, 2, 3, 4, 5}
-	res, err = get_range(obj1, 3, -1)
+	res, err = get_range(nil, obj1, 3, -1)
 	t.Logf("err: %v, res: %v", err, res)
 	if err != nil {
 		t.Errorf("failed to get_range: %v", err)
@@ -152,26 +152,26 @@ func Test_jsonpath_get_range(t *testing.T) {
 		t.Errorf("failed get_range: %v, expect: [4,5]", res)
 	}
 
-	res, err = get_range(obj1, nil, 2)
+	res, err = get_range(nil, obj1, nil, 2)
 	t.Logf("err: %v, res:%v", err, res)
 	if res.([]interface{})[0] != 1 || res.([]interface{})[1] != 2 {
 		t.Errorf("from support nil failed: %v", res)
 	}
 
-	res, err = get_range(obj1, nil, nil)
+	res, err = get_range(nil, obj1, nil, nil)
 	t.Logf("err: %v, res:%v", err, res)
 	if len(res.([]interface{})) != 5 {
 		t.Errorf("from, to both nil failed")
 	}
 
-	res, err = get_range(obj1, -2, nil)
+	res, err = get_range(nil, obj1, -2, nil)
 	t.Logf("err: %v, res:%v", err, res)
 	if res.([]interface{})[0] != 4 || res.([]interface{})[1] != 5 {
 		t.Errorf("from support nil failed: %v", res)
 	}
 
 	obj2 := 2
-	res, err = get_range(obj2, 0, 1)
+	res, err = get_range(nil, obj2, 0, 1)
 	t.Logf("err: %v, res: %v", err, res)
 	if err == nil {
 		t.Errorf("object is Slice error not raised")
@@ -182,7 +182,7 @@ func Test_jsonpath_get_scan(t *testing.T) {
 	obj := map[string]interface{}{
 		"key": 1,
 	}
-	res, err := get_scan(obj)
+	res, err := get_scan(nil, obj)
 	if err != nil {
 		t.Errorf("failed to scan: %v", err)
 		return
@@ -193,14 +193,14 @@ func Test_jsonpath_get_scan(t *testing.T) {
 	}
 
 	obj2 := 1
-	res, err = get_scan(obj2)
+	res, err = get_scan(nil, obj2)
 	if err == nil || err.Error() != "object is not scannable: int" {
 		t.Errorf("object is not scannable error not raised")
 		return
 	}
 
 	obj3 := map[string]string{"key1": "hah1", "key2": "hah2", "key3": "hah3"}
-	res, err = get_scan(obj3)
+	res, err = get_scan(nil, obj3)
 	if err != nil {
 		t.Errorf("failed to scan: %v", err)
 		return
@@ -233,7 +233,7 @@ func Test_jsonpath_get_scan(t *testing.T) {
 		"key4": []interface{}{1, 2, 3},
 		"key5": nil,
 	}
-	res, err = get_scan(obj4)
+	res, err = get_scan(nil, obj4)
 	res_v, ok = res.([]interface{})
 	if !ok {
 		t.Errorf("scanned result is not a slice")
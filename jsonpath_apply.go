@@ -0,0 +1,178 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrTestFailed is returned by ApplyPatch when a test operation does not
+// match the document.
+var ErrTestFailed = errors.New("test operation failed")
+
+// ApplyPatch applies a JSON Patch (RFC 6902) to doc and returns the result.
+// The operations are applied to a deep copy of doc one after another, so
+// doc is left untouched and, when an operation fails, none of them take
+// effect. Values are converted to the type of the location they are written
+// to like in Set.
+func ApplyPatch(doc interface{}, patch Patch) (interface{}, error) {
+	res, err := newCopier(nil).object(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range patch {
+		res, err = applyPatchOp(res, op)
+		if err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return res, nil
+}
+
+func applyPatchOp(doc interface{}, op PatchOp) (interface{}, error) {
+	path, err := pointerTokens(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace":
+		return pointerEdit(doc, path, op.Op, deepCopy(op.Value))
+	case "remove":
+		return pointerEdit(doc, path, op.Op, nil)
+	case "test":
+		v, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalValues(v, op.Value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	case "move", "copy":
+		from, err := pointerTokens(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return pointerEdit(doc, path, "add", deepCopy(v))
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		if op.Path == op.From {
+			return doc, nil
+		}
+		doc, err = pointerEdit(doc, from, "remove", nil)
+		if err != nil {
+			return nil, err
+		}
+		return pointerEdit(doc, path, "add", v)
+	}
+	return nil, fmt.Errorf("unknown patch operation %q", op.Op)
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7386) to doc and returns
+// the result: members of patch objects are merged recursively, null members
+// remove the member, and any other value replaces the target. Struct fields
+// can not be removed and are set to their zero value instead. doc is left
+// untouched.
+func ApplyMergePatch(doc interface{}, patch interface{}) (interface{}, error) {
+	res, err := newCopier(nil).object(doc)
+	if err != nil {
+		return nil, err
+	}
+	return mergePatch(res, patch)
+}
+
+func mergePatch(target interface{}, patch interface{}) (interface{}, error) {
+	pv := indirectValue(reflect.ValueOf(patch))
+	members, ok := objectEntries(pv)
+	if !ok {
+		return deepCopy(patch), nil
+	}
+
+	tv := reflect.ValueOf(target)
+	if tv.Kind() == reflect.Ptr && !tv.IsNil() {
+		newVal, err := mergePatch(tv.Elem().Interface(), patch)
+		if err != nil {
+			return nil, err
+		}
+		converted, err := convertValue(newVal, tv.Type().Elem())
+		if err != nil {
+			return nil, err
+		}
+		tv.Elem().Set(converted)
+		return target, nil
+	}
+
+	switch tv.Kind() {
+	case reflect.Map:
+		if tv.IsNil() {
+			tv = reflect.MakeMap(tv.Type())
+		}
+		for name, m := range members {
+			key, err := editMapKey(tv, name)
+			if err != nil {
+				return nil, err
+			}
+			if !indirectValue(m).IsValid() {
+				tv.SetMapIndex(key, reflect.Value{})
+				continue
+			}
+			var current interface{}
+			if cv := tv.MapIndex(key); cv.IsValid() {
+				current = cv.Interface()
+			}
+			newVal, err := mergePatch(current, m.Interface())
+			if err != nil {
+				return nil, err
+			}
+			converted, err := convertValue(newVal, tv.Type().Elem())
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %v", name, err)
+			}
+			tv.SetMapIndex(key, converted)
+		}
+		return tv.Interface(), nil
+
+	case reflect.Struct:
+		newStruct := reflect.New(tv.Type()).Elem()
+		newStruct.Set(tv)
+		for name, m := range members {
+			f, ok := lookupField(tv.Type(), name)
+			if !ok {
+				return nil, notFound("key error: %s not found in struct", name)
+			}
+			field, err := settableFieldByIndex(newStruct, f.index, false)
+			if err != nil {
+				return nil, err
+			}
+			if !indirectValue(m).IsValid() {
+				field.Set(reflect.Zero(field.Type()))
+				continue
+			}
+			newVal, err := mergePatch(field.Interface(), m.Interface())
+			if err != nil {
+				return nil, err
+			}
+			converted, err := convertValue(newVal, field.Type())
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", name, err)
+			}
+			field.Set(converted)
+		}
+		return newStruct.Interface(), nil
+	}
+
+	// any other target is replaced by an object
+	return mergePatch(map[string]interface{}{}, patch)
+}
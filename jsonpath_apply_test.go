@@ -0,0 +1,280 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func mustJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", s, err)
+	}
+	return v
+}
+
+func mustPatch(t *testing.T, s string) Patch {
+	t.Helper()
+	var p Patch
+	if err := json.Unmarshal([]byte(s), &p); err != nil {
+		t.Fatalf("invalid patch %s: %v", s, err)
+	}
+	return p
+}
+
+// examples from RFC 6902 appendix A
+func TestApplyPatch_RFC6902(t *testing.T) {
+	tests := []struct {
+		doc, patch, expected string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{`{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+	for _, tt := range tests {
+		doc := mustJSON(t, tt.doc)
+		res, err := ApplyPatch(doc, mustPatch(t, tt.patch))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.patch, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, mustJSON(t, tt.expected)) {
+			t.Errorf("%s: expected %s, got %v", tt.patch, tt.expected, res)
+		}
+		if !reflect.DeepEqual(doc, mustJSON(t, tt.doc)) {
+			t.Errorf("%s: original was modified", tt.patch)
+		}
+	}
+}
+
+func TestApplyPatch_Errors(t *testing.T) {
+	tests := []struct {
+		doc, patch string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{`{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`},
+		{`{"foo":["a"]}`, `[{"op":"add","path":"/foo/2","value":1}]`},
+		{`{"foo":["a"]}`, `[{"op":"add","path":"/foo/01","value":1}]`},
+		{`{"foo":{"a":1}}`, `[{"op":"move","from":"/foo","path":"/foo/b"}]`},
+		{`{"foo":"bar"}`, `[{"op":"test","path":"/foo","value":"baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"unknown","path":"/foo"}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"foo","value":1}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/~2","value":1}]`},
+	}
+	for _, tt := range tests {
+		if res, err := ApplyPatch(mustJSON(t, tt.doc), mustPatch(t, tt.patch)); err == nil {
+			t.Errorf("%s: expected error, got %v", tt.patch, res)
+		}
+	}
+
+	_, err := ApplyPatch(mustJSON(t, `{"foo":"bar"}`), mustPatch(t, `[{"op":"test","path":"/foo","value":"baz"}]`))
+	if !errors.Is(err, ErrTestFailed) {
+		t.Errorf("expected ErrTestFailed, got %v", err)
+	}
+}
+
+func TestApplyPatch_Atomic(t *testing.T) {
+	doc := map[string]interface{}{"a": map[string]interface{}{"b": 1}, "list": []interface{}{1, 2}}
+	patch := Patch{
+		{Op: "replace", Path: "/a/b", Value: 2},
+		{Op: "remove", Path: "/list/0"},
+		{Op: "remove", Path: "/missing"},
+	}
+	if _, err := ApplyPatch(doc, patch); err == nil {
+		t.Fatal("expected error")
+	}
+	expected := map[string]interface{}{"a": map[string]interface{}{"b": 1}, "list": []interface{}{1, 2}}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("document was modified: %v", doc)
+	}
+}
+
+func TestApplyPatch_TypedValues(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+		Qty  int    `json:"qty"`
+	}
+	type order struct {
+		Items  []item         `json:"items"`
+		Counts map[string]int `json:"counts"`
+		Ship   *structAddress `json:"ship"`
+	}
+	doc := &order{Items: []item{{"a", 1}}, Counts: map[string]int{"a": 1}, Ship: &structAddress{City: "Oslo"}}
+	patch := Patch{
+		{Op: "add", Path: "/items/-", Value: map[string]interface{}{"name": "b", "qty": 2.0}},
+		{Op: "replace", Path: "/items/0/qty", Value: 3.0},
+		{Op: "add", Path: "/counts/b", Value: 2.0},
+		{Op: "replace", Path: "/ship/city", Value: "Bergen"},
+		{Op: "test", Path: "/items/1/qty", Value: 2},
+		{Op: "test", Path: "/items/1", Value: map[string]interface{}{"name": "b", "qty": 2.0}},
+	}
+	res, err := ApplyPatch(doc, patch)
+	if err != nil {
+		t.Fatalf("patch failed: %v", err)
+	}
+	got := res.(*order)
+	expected := &order{
+		Items:  []item{{"a", 3}, {"b", 2}},
+		Counts: map[string]int{"a": 1, "b": 2},
+		Ship:   &structAddress{City: "Bergen"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	if doc.Ship.City != "Oslo" || len(doc.Items) != 1 {
+		t.Error("original was modified")
+	}
+
+	if _, err := ApplyPatch(doc, Patch{{Op: "remove", Path: "/ship"}}); err == nil {
+		t.Error("expected error removing a struct field")
+	}
+	if _, err := ApplyPatch(doc, Patch{{Op: "replace", Path: "/items/0/qty", Value: "many"}}); err == nil {
+		t.Error("expected conversion error")
+	}
+}
+
+func TestApplyPatch_RoundTrip(t *testing.T) {
+	doc := patchDoc()
+	res, patch, err := MustCompile("$.book[0:2].price").SetWithPatch(doc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := ApplyPatch(doc, patch)
+	if err != nil {
+		t.Fatalf("patch failed: %v", err)
+	}
+	if !reflect.DeepEqual(applied, res) {
+		t.Errorf("expected %v, got %v", res, applied)
+	}
+
+	res, patch, err = MustCompile("$.book[0:2]").DeleteWithPatch(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err = ApplyPatch(doc, patch)
+	if err != nil {
+		t.Fatalf("patch failed: %v", err)
+	}
+	if !reflect.DeepEqual(applied, res) {
+		t.Errorf("expected %v, got %v", res, applied)
+	}
+}
+
+// examples from RFC 7386 appendix A
+func TestApplyMergePatch_RFC7386(t *testing.T) {
+	tests := []struct {
+		doc, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		doc := mustJSON(t, tt.doc)
+		res, err := ApplyMergePatch(doc, mustJSON(t, tt.patch))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.patch, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, mustJSON(t, tt.expected)) {
+			t.Errorf("%s: expected %s, got %v", tt.patch, tt.expected, res)
+		}
+		if !reflect.DeepEqual(doc, mustJSON(t, tt.doc)) {
+			t.Errorf("%s: original was modified", tt.patch)
+		}
+	}
+}
+
+func TestApplyMergePatch_Typed(t *testing.T) {
+	type settings struct {
+		Name   string            `json:"name"`
+		Port   int               `json:"port"`
+		Labels map[string]string `json:"labels"`
+		Home   *structAddress    `json:"home"`
+	}
+	doc := settings{Name: "a", Port: 80, Labels: map[string]string{"x": "1", "y": "2"}}
+	patch := mustJSON(t, `{"port":8080,"labels":{"x":null,"z":"3"},"home":{"city":"Oslo"},"name":null}`)
+	res, err := ApplyMergePatch(doc, patch)
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	expected := settings{Port: 8080, Labels: map[string]string{"y": "2", "z": "3"}, Home: &structAddress{City: "Oslo"}}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %+v, got %+v", expected, res)
+	}
+	if doc.Labels["x"] != "1" {
+		t.Error("original was modified")
+	}
+
+	if _, err := ApplyMergePatch(doc, mustJSON(t, `{"port":"high"}`)); err == nil {
+		t.Error("expected conversion error")
+	}
+	if _, err := ApplyMergePatch(doc, mustJSON(t, `{"unknown":1}`)); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestEqualValues(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+	}
+	equal := [][2]interface{}{
+		{1, 1.0},
+		{json.Number("2"), int8(2)},
+		{map[string]interface{}{"x": 1.0}, point{1}},
+		{[]interface{}{1, "a"}, [2]interface{}{1.0, "a"}},
+		{&point{1}, point{1}},
+		{nil, []int(nil)},
+	}
+	for _, pair := range equal {
+		if !equalValues(pair[0], pair[1]) {
+			t.Errorf("expected %v and %v to be equal", pair[0], pair[1])
+		}
+	}
+	different := [][2]interface{}{
+		{1, "1"},
+		{[]int{}, nil},
+		{map[string]int{"a": 1}, map[string]int{"a": 1, "b": 2}},
+		{true, 1},
+		{[]int{1}, []int{1, 2}},
+	}
+	for _, pair := range different {
+		if equalValues(pair[0], pair[1]) {
+			t.Errorf("expected %v and %v to differ", pair[0], pair[1])
+		}
+	}
+}
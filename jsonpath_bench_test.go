@@ -32,6 +32,20 @@ func BenchmarkJsonPathLookup(b *testing.B) {
 	}
 }
 
+func BenchmarkJsonPathLookupDefaultCache(b *testing.B) {
+	SetDefaultCache(NewCache(16))
+	defer SetDefaultCache(nil)
+	for n := 0; n < b.N; n++ {
+		res, err := JsonPathLookup(json_data, "$.store.book[0].price")
+		if res_v, ok := res.(float64); ok != true || res_v != 8.95 {
+			b.Errorf("$.store.book[0].price should be 8.95")
+		}
+		if err != nil {
+			b.Errorf("Unexpected error: %v", err)
+		}
+	}
+}
+
 func BenchmarkJsonPathLookup_0(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		JsonPathLookup(json_data, "$.expensive")
@@ -193,3 +207,43 @@ func BenchmarkCompileAndLookup(b *testing.B) {
 		c.Lookup(data)
 	}
 }
+
+func BenchmarkJsonPathLookup_Struct(b *testing.B) {
+	type Inner struct {
+		Price float64 `json:"price"`
+	}
+	type Outer struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Notes string `json:"notes,omitempty"`
+		Inner Inner  `json:"inner"`
+	}
+	obj := Outer{ID: 1, Name: "x", Inner: Inner{Price: 8.95}}
+	c := MustCompile("$.inner.price")
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		c.Lookup(obj)
+	}
+}
+
+func BenchmarkQuerySetLookup(b *testing.B) {
+	qs, err := NewQuerySet(querySetPaths...)
+	if err != nil {
+		b.Fatalf("%v", err)
+	}
+	for n := 0; n < b.N; n++ {
+		qs.Lookup(json_data)
+	}
+}
+
+func BenchmarkQuerySetSeparateLookups(b *testing.B) {
+	compiled := []*Compiled{}
+	for _, p := range querySetPaths {
+		compiled = append(compiled, MustCompile(p))
+	}
+	for n := 0; n < b.N; n++ {
+		for _, c := range compiled {
+			c.Lookup(json_data)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrNoMatch is the error of a field whose path matches nothing.
+var ErrNoMatch = errors.New("path matches nothing")
+
+// ErrField is the error of a single field in Bind.
+type ErrField struct {
+	// Field is the name of the field, with the names of the structs it is
+	// nested in, like Address.City.
+	Field string
+	Path  string
+	Err   error
+}
+
+func (e *ErrField) Error() string {
+	return fmt.Sprintf("field %s (%s): %v", e.Field, e.Path, e.Err)
+}
+
+func (e *ErrField) Unwrap() error {
+	return e.Err
+}
+
+// ErrFields holds the errors of all fields Bind could not set.
+type ErrFields []*ErrField
+
+func (e ErrFields) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	if len(e) == 1 {
+		return msgs[0]
+	}
+	return fmt.Sprintf("%d fields failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// UnmarshalPaths decodes the JSON data and binds it to target like Bind.
+// Numbers are decoded as json.Number, so they convert to integer fields
+// without losing precision and are json.Number in interface{} fields.
+func UnmarshalPaths(data []byte, target interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var doc interface{}
+	if err := d.Decode(&doc); err != nil {
+		return err
+	}
+	return Bind(doc, target)
+}
+
+// Bind sets the fields of the struct target points to from doc. Every field
+// with a `jsonpath:"$.a.b"` tag is set to the result of looking up the path
+// in doc, converted to the type of the field like Set converts values. Paths
+// that can match several values, such as $.items[*].id, fill slice fields
+// with all matches and other fields with the only match. A tag like
+// `jsonpath:"$.a.b,optional"` leaves the field untouched when the path
+// matches nothing, and so does omitempty, as Project leaves out empty
+// fields with that option. Fields of struct type without a tag are bound
+// the same way, so tagged fields can be grouped in nested structs.
+//
+// Bind sets every field it can and returns the errors of all others as
+// ErrFields, each an *ErrField.
+func Bind(doc interface{}, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind target must be a non-nil pointer to a struct, not %T", target)
+	}
+	var errs ErrFields
+	bindStruct(doc, v.Elem(), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// pathTag is a parsed jsonpath struct tag.
+type pathTag struct {
+	path    string
+	options []string
+}
+
+func parsePathTag(tag string) pathTag {
+	parts := strings.Split(tag, ",")
+	return pathTag{path: strings.TrimSpace(parts[0]), options: parts[1:]}
+}
+
+func (t pathTag) has(option string) bool {
+	for _, o := range t.options {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
+
+func bindStruct(doc interface{}, v reflect.Value, prefix string, errs *ErrFields) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name := prefix + sf.Name
+		tag, ok := sf.Tag.Lookup("jsonpath")
+		if !ok {
+			if sf.Type.Kind() == reflect.Struct && (sf.Anonymous || v.Field(i).CanSet()) {
+				bindStruct(doc, v.Field(i), name+".", errs)
+			}
+			continue
+		}
+		pt := parsePathTag(tag)
+		if pt.path == "-" {
+			continue
+		}
+		if !v.Field(i).CanSet() {
+			*errs = append(*errs, &ErrField{name, pt.path, errors.New("field is unexported")})
+			continue
+		}
+		if err := bindField(doc, v.Field(i), pt); err != nil {
+			if err == ErrNoMatch && (pt.has("optional") || pt.has("omitempty")) {
+				continue
+			}
+			*errs = append(*errs, &ErrField{name, pt.path, err})
+		}
+	}
+}
+
+func bindField(doc interface{}, field reflect.Value, pt pathTag) error {
+	c, err := Compile(pt.path)
+	if err != nil {
+		return err
+	}
+	matches, single, err := c.LookupMatches(doc)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrNoMatch
+		}
+		return err
+	}
+
+	typ := field.Type()
+	var res interface{}
+	switch {
+	case single:
+		res = matches[0]
+		if isListType(typ) && !isListType(reflect.TypeOf(res)) && res != nil {
+			// a single match fills a slice of one element
+			res = []interface{}{res}
+		}
+	case len(matches) == 0:
+		return ErrNoMatch
+	case isListType(typ):
+		res = matches
+	case len(matches) > 1:
+		return fmt.Errorf("path matches %d values", len(matches))
+	default:
+		res = matches[0]
+	}
+
+	converted, err := convertValue(res, typ)
+	if err != nil {
+		return err
+	}
+	field.Set(converted)
+	return nil
+}
+
+// isListType reports whether values of typ are stored as JSON arrays.
+func isListType(typ reflect.Type) bool {
+	if typ == nil {
+		return false
+	}
+	switch typ.Kind() {
+	case reflect.Slice:
+		return typ.Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const bindPayload = `{
+	"data": {
+		"id": 9007199254740993,
+		"attributes": {"name": "Ann", "tags": ["a", "b"], "score": 4.5, "active": true},
+		"items": [{"sku": "x1", "qty": 2}, {"sku": "y2", "qty": 1}]
+	}
+}`
+
+type bindAddress struct {
+	Sku string `jsonpath:"$.data.items[0].sku"`
+}
+
+type bindBase struct {
+	Active bool `jsonpath:"$.data.attributes.active"`
+}
+
+type bindTarget struct {
+	bindBase
+	ID      int64    `jsonpath:"$.data.id"`
+	Name    string   `jsonpath:"$.data.attributes.name"`
+	NamePtr *string  `jsonpath:"$.data.attributes.name"`
+	Tags    []string `jsonpath:"$.data.attributes.tags"`
+	Score   float32  `jsonpath:"$.data.attributes.score"`
+	Skus    []string `jsonpath:"$.data.items[*].sku"`
+	Qty     []int    `jsonpath:"$.data.items[*].qty"`
+	BigQty  string   `jsonpath:"$.data.items[?(@.qty > 1)].sku"`
+	Single  []string `jsonpath:"$.data.attributes.name"`
+	Item    struct {
+		Sku string `json:"sku"`
+		Qty uint8  `json:"qty"`
+	} `jsonpath:"$.data.items[1]"`
+	Raw      interface{} `jsonpath:"$.data.attributes.score"`
+	Nickname string      `jsonpath:"$.data.attributes.nickname,optional"`
+	Address  bindAddress
+	Ignored  string `jsonpath:"-"`
+	Untagged string
+}
+
+func TestUnmarshalPaths(t *testing.T) {
+	var got bindTarget
+	got.Nickname = "keep"
+	if err := UnmarshalPaths([]byte(bindPayload), &got); err != nil {
+		t.Fatal(err)
+	}
+	name := "Ann"
+	want := bindTarget{
+		bindBase: bindBase{Active: true},
+		ID:       9007199254740993,
+		Name:     "Ann",
+		NamePtr:  &name,
+		Tags:     []string{"a", "b"},
+		Score:    4.5,
+		Skus:     []string{"x1", "y2"},
+		Qty:      []int{2, 1},
+		BigQty:   "x1",
+		Single:   []string{"Ann"},
+		Raw:      json.Number("4.5"),
+		Nickname: "keep",
+		Address:  bindAddress{Sku: "x1"},
+	}
+	want.Item.Sku, want.Item.Qty = "y2", 1
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestBind_Errors(t *testing.T) {
+	var target struct {
+		Name    int      `jsonpath:"$.data.attributes.name"`
+		Missing string   `jsonpath:"$.data.attributes.missing"`
+		Empty   []string `jsonpath:"$.data.items[?(@.qty > 5)].sku"`
+		Many    string   `jsonpath:"$.data.items[*].sku"`
+		Bad     string   `jsonpath:"data"`
+		Score   float64  `jsonpath:"$.data.attributes.score"`
+		hidden  string   `jsonpath:"$.data.id"`
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(bindPayload), &doc); err != nil {
+		t.Fatal(err)
+	}
+	err := Bind(doc, &target)
+	errs, ok := err.(ErrFields)
+	if !ok {
+		t.Fatalf("expected ErrFields, got %T %v", err, err)
+	}
+	fields := []string{}
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	if want := []string{"Name", "Missing", "Empty", "Many", "Bad"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("failed fields %v, want %v", fields, want)
+	}
+	if !errors.Is(errs[1], ErrNoMatch) || !errors.Is(errs[2], ErrNoMatch) {
+		t.Errorf("missing fields should wrap ErrNoMatch: %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "5 fields failed: field Name ($.data.attributes.name): ") {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if target.Score != 4.5 {
+		t.Errorf("fields without errors should still be set, got %v", target.Score)
+	}
+	if target.hidden != "" {
+		t.Errorf("unexported fields should be skipped")
+	}
+}
+
+func TestBind_OmitEmpty(t *testing.T) {
+	target := struct {
+		Nickname string  `jsonpath:"$.data.attributes.nickname,omitempty"`
+		Tags     []int   `jsonpath:"$.data.items[?(@.qty > 5)].qty,omitempty"`
+		Score    float64 `jsonpath:"$.data.attributes.score,omitempty"`
+	}{Nickname: "kept"}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(bindPayload), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := Bind(doc, &target); err != nil {
+		t.Fatal(err)
+	}
+	if target.Nickname != "kept" || target.Tags != nil || target.Score != 4.5 {
+		t.Errorf("unexpected target %+v", target)
+	}
+}
+
+func TestBind_InvalidTarget(t *testing.T) {
+	var s struct{}
+	for _, target := range []interface{}{nil, s, (*struct{})(nil), new(int)} {
+		if err := Bind(map[string]interface{}{}, target); err == nil {
+			t.Errorf("%T: expected an error", target)
+		}
+	}
+	if err := UnmarshalPaths([]byte("{"), &s); err == nil {
+		t.Errorf("expected a syntax error")
+	}
+}
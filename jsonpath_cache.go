@@ -0,0 +1,148 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a size-bounded cache of compiled paths that evicts the least
+// recently used path when full. It is safe for concurrent use.
+type Cache struct {
+	mu        sync.Mutex
+	capacity  int
+	order     *list.List // of *cacheEntry, most recently used first
+	entries   map[string]*list.Element
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type cacheEntry struct {
+	path     string
+	compiled *Compiled
+}
+
+// CacheStats are the counters of a Cache.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	// Len is the number of cached paths and Capacity the most it holds.
+	Len      int
+	Capacity int
+}
+
+// NewCache returns a cache holding up to capacity compiled paths. A
+// capacity below 1 is taken as 1.
+func NewCache(capacity int) *Cache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Cache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Compile returns the cached compilation of jpath, compiling and caching
+// it on a miss. Paths that fail to compile are not cached. The result is
+// shared, so use WithSetMode and the like to derive a changed copy.
+func (c *Cache) Compile(jpath string) (*Compiled, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[jpath]; ok {
+		c.order.MoveToFront(e)
+		c.hits++
+		compiled := e.Value.(*cacheEntry).compiled
+		c.mu.Unlock()
+		return compiled, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// compile outside the lock, a concurrent miss on the same path at
+	// worst compiles it twice
+	compiled, err := Compile(jpath)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[jpath]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*cacheEntry).compiled, nil
+	}
+	c.entries[jpath] = c.order.PushFront(&cacheEntry{jpath, compiled})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).path)
+		c.evictions++
+	}
+	return compiled, nil
+}
+
+// Lookup compiles jpath through the cache and looks it up in obj.
+func (c *Cache) Lookup(obj interface{}, jpath string) (interface{}, error) {
+	compiled, err := c.Compile(jpath)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.Lookup(obj)
+}
+
+// Stats returns the current counters of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Len:       c.order.Len(),
+		Capacity:  c.capacity,
+	}
+}
+
+// Purge removes all paths from the cache and resets its counters.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.hits, c.misses, c.evictions = 0, 0, 0
+}
+
+var (
+	defaultCacheMu sync.RWMutex
+	defaultCache   *Cache
+)
+
+// SetDefaultCache makes JsonPathLookup, JsonPathSet and JsonPathDelete
+// compile their paths through cache, or recompile them on every call, the
+// default, when cache is nil.
+func SetDefaultCache(cache *Cache) {
+	defaultCacheMu.Lock()
+	defaultCache = cache
+	defaultCacheMu.Unlock()
+}
+
+// DefaultCache returns the cache set with SetDefaultCache, or nil.
+func DefaultCache() *Cache {
+	defaultCacheMu.RLock()
+	defer defaultCacheMu.RUnlock()
+	return defaultCache
+}
+
+// compileDefault compiles jpath through the default cache, if any.
+func compileDefault(jpath string) (*Compiled, error) {
+	if cache := DefaultCache(); cache != nil {
+		return cache.Compile(jpath)
+	}
+	return Compile(jpath)
+}
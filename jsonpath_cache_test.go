@@ -0,0 +1,113 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCache_LRU(t *testing.T) {
+	c := NewCache(2)
+	a, err := c.Compile("$.a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := c.Compile("$.a"); again != a {
+		t.Errorf("expected the cached *Compiled")
+	}
+	c.Compile("$.b")
+	c.Compile("$.a") // $.b is now the least recently used
+	c.Compile("$.c") // and evicted
+
+	want := CacheStats{Hits: 2, Misses: 3, Evictions: 1, Len: 2, Capacity: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if again, _ := c.Compile("$.a"); again != a {
+		t.Errorf("$.a should still be cached")
+	}
+	c.Compile("$.b")
+	if got := c.Stats(); got.Misses != 4 || got.Evictions != 2 {
+		t.Errorf("$.b should have been evicted: %+v", got)
+	}
+
+	c.Purge()
+	if got := c.Stats(); got != (CacheStats{Capacity: 2}) {
+		t.Errorf("after Purge got %+v", got)
+	}
+}
+
+func TestCache_Errors(t *testing.T) {
+	c := NewCache(0)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Compile("a.b"); err == nil {
+			t.Fatal("expected a compile error")
+		}
+	}
+	if got := c.Stats(); got.Misses != 2 || got.Len != 0 || got.Capacity != 1 {
+		t.Errorf("got %+v", got)
+	}
+	if _, err := c.Lookup(json_data, "$.store.car"); err == nil {
+		t.Errorf("expected a lookup error")
+	}
+	if res, err := c.Lookup(json_data, "$.expensive"); err != nil || res != 10.0 {
+		t.Errorf("got %v %v", res, err)
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	c := NewCache(8)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				path := fmt.Sprintf("$.store.book[%d].price", (g+i)%12)
+				if _, err := c.Compile(path); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	s := c.Stats()
+	if s.Hits+s.Misses != 1600 || s.Len > 8 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestSetDefaultCache(t *testing.T) {
+	cache := NewCache(4)
+	SetDefaultCache(cache)
+	defer SetDefaultCache(nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := JsonPathLookup(json_data, "$.store.bicycle.color"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := JsonPathSet(json_data, "$.store.bicycle.color", "blue"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := JsonPathDelete(json_data, "$.store.bicycle.color"); err != nil {
+		t.Fatal(err)
+	}
+	if got := cache.Stats(); got.Hits != 4 || got.Misses != 1 {
+		t.Errorf("got %+v", got)
+	}
+
+	SetDefaultCache(nil)
+	if DefaultCache() != nil {
+		t.Errorf("expected no default cache")
+	}
+	JsonPathLookup(json_data, "$.store.bicycle.color")
+	if got := cache.Stats(); got.Hits != 4 {
+		t.Errorf("the cache should not be used any more: %+v", got)
+	}
+}
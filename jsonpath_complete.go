@@ -0,0 +1,120 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Complete returns the completions of a partially typed path, for example
+// $.store.b completes to $.store.bicycle and $.store.book. The path up to
+// the last dot is looked up in obj like Lookup does and the keys of the
+// result that start with the rest are offered. When the result is an
+// array, the keys of its elements are offered, since a key step on an
+// array selects the key of every element. Keys that need quoting are
+// quoted. Complete returns nil when nothing matches or the path is invalid.
+func Complete(obj interface{}, partial string) []string {
+	base, prefix := "$", ""
+	if partial != "" && partial != "$" {
+		i := lastDot(partial)
+		if i < 1 || partial[i-1] == '.' {
+			// no dot, or a recursive descent like $..a
+			return nil
+		}
+		base, prefix = partial[:i], partial[i+1:]
+	}
+	if strings.HasPrefix(prefix, `"`) {
+		prefix = unescapeKey(prefix[1:])
+	}
+
+	c, err := Compile(base)
+	if err != nil {
+		return nil
+	}
+	res, err := c.Lookup(obj)
+	if err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	v := indirectValue(reflect.ValueOf(res))
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			addKeys(seen, indirectValue(v.Index(i)), prefix)
+		}
+	} else {
+		addKeys(seen, v, prefix)
+	}
+
+	completions := make([]string, 0, len(seen))
+	for key := range seen {
+		if isPlainKey(key) {
+			completions = append(completions, base+"."+key)
+		} else {
+			completions = append(completions, base+"."+quoteKey(key))
+		}
+	}
+	sort.Strings(completions)
+	if len(completions) == 0 {
+		return nil
+	}
+	return completions
+}
+
+// addKeys adds the keys of the object v that start with prefix to seen.
+func addKeys(seen map[string]bool, v reflect.Value, prefix string) {
+	entries, ok := objectEntries(v)
+	if !ok {
+		return
+	}
+	for key := range entries {
+		if strings.HasPrefix(key, prefix) {
+			seen[key] = true
+		}
+	}
+}
+
+// lastDot returns the index of the last dot of path that is not inside
+// quotes, brackets or parentheses, or -1. A quoted key that is still being
+// typed, like $."a b, counts as quoted, and a backslash escapes the next
+// character in it.
+func lastDot(path string) int {
+	last, depth := -1, 0
+	var quote byte
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == '.' && depth == 0:
+			last = i
+		}
+	}
+	return last
+}
+
+// unescapeKey undoes quoteKey for the part of a quoted key typed so far.
+func unescapeKey(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] == '\\' && i+1 < len(key) {
+			i++
+		}
+		sb.WriteByte(key[i])
+	}
+	return sb.String()
+}
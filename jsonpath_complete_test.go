@@ -0,0 +1,75 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	doc := map[string]interface{}{
+		"store": map[string]interface{}{
+			"book": []interface{}{
+				map[string]interface{}{"author": "a", "price": 1},
+				map[string]interface{}{"author": "b", "isbn": "x"},
+			},
+			"bicycle":  map[string]interface{}{"color": "red"},
+			"gift box": true,
+			`say "hi"`: 1,
+			`a\b`:      2,
+		},
+		"expensive": 10,
+	}
+	tests := []struct {
+		partial string
+		want    []string
+	}{
+		{"", []string{"$.expensive", "$.store"}},
+		{"$", []string{"$.expensive", "$.store"}},
+		{"$.", []string{"$.expensive", "$.store"}},
+		{"$.st", []string{"$.store"}},
+		{"$.store.b", []string{"$.store.bicycle", "$.store.book"}},
+		{"$.store.g", []string{`$.store."gift box"`}},
+		{`$.store."gift`, []string{`$.store."gift box"`}},
+		{"$.store.s", []string{`$.store."say \"hi\""`}},
+		{`$.store."say \"h`, []string{`$.store."say \"hi\""`}},
+		{"$.store.a", []string{`$.store."a\\b"`}},
+		{`$.store."a\\`, []string{`$.store."a\\b"`}},
+		{"$.store.book.", []string{"$.store.book.author", "$.store.book.isbn", "$.store.book.price"}},
+		{"$.store.book[1].", []string{"$.store.book[1].author", "$.store.book[1].isbn"}},
+		{"$.store.book[?(@.price > 0.5)].a", []string{"$.store.book[?(@.price > 0.5)].author"}},
+		{"$.store.x", nil},
+		{"$.missing.", nil},
+		{"$.expensive.", nil},
+		{"$..a", nil},
+		{"store", nil},
+	}
+	for _, tt := range tests {
+		if got := Complete(doc, tt.partial); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q) = %v, want %v", tt.partial, got, tt.want)
+		}
+		for _, p := range tt.want {
+			if _, err := JsonPathLookup(doc, p); err != nil {
+				t.Errorf("Lookup(%s) failed: %v", p, err)
+			}
+		}
+	}
+}
+
+func TestComplete_Struct(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Count int
+		skip  int
+	}
+	doc := struct {
+		Items []item `json:"items"`
+	}{Items: []item{{Name: "a"}}}
+	want := []string{"$.items.Count", "$.items.name"}
+	if got := Complete(doc, "$.items."); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func contextTestDoc(n int) map[string]interface{} {
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i, "tags": []interface{}{"a", "b"}}
+	}
+	return map[string]interface{}{"items": items}
+}
+
+func TestLookupContext_SameAsLookup(t *testing.T) {
+	doc := contextTestDoc(10)
+	for _, p := range []string{"$.items[?(@.id > 5)].id", "$.items[0].tags", "$..id"} {
+		c := MustCompile(p)
+		exp, err := c.Lookup(doc)
+		if err != nil {
+			t.Fatalf("Lookup(%s) failed: %v", p, err)
+		}
+		res, err := c.LookupContext(context.Background(), doc)
+		if err != nil {
+			t.Fatalf("LookupContext(%s) failed: %v", p, err)
+		}
+		if !reflect.DeepEqual(res, exp) {
+			t.Errorf("%s: expected %v, got %v", p, exp, res)
+		}
+	}
+}
+
+func TestLookupContext_Canceled(t *testing.T) {
+	doc := contextTestDoc(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, p := range []string{"$..id", "$.items[?(@.id > 5)]", "$.expensive"} {
+		_, err := MustCompile(p).LookupContext(ctx, doc)
+		if err != context.Canceled {
+			t.Errorf("%s: expected context.Canceled, got %v", p, err)
+		}
+	}
+}
+
+func TestLookupContext_DeadlineExceeded(t *testing.T) {
+	doc := contextTestDoc(1000)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := MustCompile("$..tags").LookupContext(ctx, doc)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestLookupContext_CanceledWhileRunning(t *testing.T) {
+	doc := contextTestDoc(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	ev := &evaluator{ctx: ctx}
+	visited := 0
+	// cancel after the first check has passed
+	for visited < ctxCheckInterval {
+		if err := ev.tick(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		visited++
+	}
+	cancel()
+	if _, err := getAllDescendants(ev, doc); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestLookupContext_CanceledWhileApplyingKey(t *testing.T) {
+	doc := contextTestDoc(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	ev := &evaluator{ctx: ctx}
+	for visited := 0; visited < ctxCheckInterval; visited++ {
+		if err := ev.tick(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	cancel()
+	if _, err := lookupKey(ev, tracedValue{value: doc["items"]}, "id"); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	// arrays of arrays stop as well
+	nested := []interface{}{doc["items"]}
+	if _, err := lookupKey(ev, tracedValue{value: nested}, "id"); err != context.Canceled {
+		t.Errorf("nested: expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSetContext(t *testing.T) {
+	doc := contextTestDoc(3)
+	c := MustCompile("$.items[1].id")
+
+	res, err := c.SetContext(context.Background(), doc, 42)
+	if err != nil {
+		t.Fatalf("SetContext failed: %v", err)
+	}
+	v, _ := JsonPathLookup(res, "$.items[1].id")
+	if v != 42 {
+		t.Errorf("expected 42, got %v", v)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.SetContext(ctx, doc, 42); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// convertValue converts value so it can be stored in a location of type typ.
+// Values assignable to typ are used as they are, nil becomes the zero value
+// of typ, numbers are converted when no precision is lost, strings and byte
+// slices convert to each other, and maps, slices and structs are converted
+// by a JSON round trip.
+func convertValue(value interface{}, typ reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(typ), nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(typ) {
+		return v, nil
+	}
+
+	switch {
+	case isNumericKind(typ.Kind()):
+		return convertNumber(value, typ)
+	case typ.Kind() == reflect.String:
+		if v.Kind() == reflect.String || v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Convert(typ), nil
+		}
+	case typ.Kind() == reflect.Bool:
+		if v.Kind() == reflect.Bool {
+			return v.Convert(typ), nil
+		}
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.String:
+		return v.Convert(typ), nil
+	case typ.Kind() == reflect.Ptr:
+		elem, err := convertValue(value, typ.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		switch v.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			return convertJSON(value, typ)
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %T to %s", value, typ)
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// convertNumber converts numbers, including json.Number and the math/big
+// types, to the numeric type typ, failing on overflow or a lost fraction.
+func convertNumber(value interface{}, typ reflect.Type) (reflect.Value, error) {
+	if _, ok := value.(string); ok {
+		return reflect.Value{}, fmt.Errorf("cannot convert string to %s", typ)
+	}
+	r, ok := numberRat(value)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("cannot convert %T to %s", value, typ)
+	}
+	res := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f, _ := r.Float64()
+		if math.IsInf(f, 0) || res.OverflowFloat(f) {
+			return reflect.Value{}, fmt.Errorf("cannot convert %v to %s: overflow", value, typ)
+		}
+		res.SetFloat(f)
+		return res, nil
+	}
+
+	if !r.IsInt() {
+		return reflect.Value{}, fmt.Errorf("cannot convert %v to %s: not an integer", value, typ)
+	}
+	n := r.Num()
+	switch typ.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n.Sign() < 0 || !n.IsUint64() || res.OverflowUint(n.Uint64()) {
+			return reflect.Value{}, fmt.Errorf("cannot convert %v to %s: overflow", value, typ)
+		}
+		res.SetUint(n.Uint64())
+	default:
+		if !n.IsInt64() || res.OverflowInt(n.Int64()) {
+			return reflect.Value{}, fmt.Errorf("cannot convert %v to %s: overflow", value, typ)
+		}
+		res.SetInt(n.Int64())
+	}
+	return res, nil
+}
+
+// convertJSON converts composite values through their JSON encoding.
+func convertJSON(value interface{}, typ reflect.Type) (reflect.Value, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("cannot convert %T to %s: %v", value, typ, err)
+	}
+	res := reflect.New(typ)
+	if err := json.Unmarshal(data, res.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("cannot convert %T to %s: %v", value, typ, err)
+	}
+	return res.Elem(), nil
+}
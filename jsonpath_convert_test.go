@@ -0,0 +1,120 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type convertLevel string
+
+type convertDoc struct {
+	Count  int               `json:"count"`
+	Ratio  float32           `json:"ratio"`
+	Small  uint8             `json:"small"`
+	Data   []byte            `json:"data"`
+	Label  string            `json:"label"`
+	Level  convertLevel      `json:"level"`
+	Tags   []string          `json:"tags"`
+	Limits map[string]int    `json:"limits"`
+	Home   structAddress     `json:"home"`
+	Meta   map[string]string `json:"meta"`
+	Ptr    *int              `json:"ptr"`
+}
+
+func TestConvertValue(t *testing.T) {
+	seven := 7
+	tests := []struct {
+		path     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"$.count", 3.0, 3},
+		{"$.count", json.Number("42"), 42},
+		{"$.ratio", 1, float32(1)},
+		{"$.small", int64(200), uint8(200)},
+		{"$.data", "raw", []byte("raw")},
+		{"$.label", []byte("bytes"), "bytes"},
+		{"$.level", "high", convertLevel("high")},
+		{"$.tags", []interface{}{"a", "b"}, []string{"a", "b"}},
+		{"$.tags", nil, []string(nil)},
+		{"$.limits", map[string]interface{}{"cpu": 2.0}, map[string]int{"cpu": 2}},
+		{"$.home", map[string]interface{}{"city": "Oslo"}, structAddress{City: "Oslo"}},
+		{"$.ptr", 7, &seven},
+		{"$.tags[1]", []byte("c"), "c"},
+	}
+	for _, tt := range tests {
+		doc := convertDoc{Tags: []string{"x", "y"}}
+		res, err := JsonPathSet(doc, tt.path, tt.value)
+		if err != nil {
+			t.Errorf("%s = %v: unexpected error %v", tt.path, tt.value, err)
+			continue
+		}
+		got, err := JsonPathLookup(res, tt.path)
+		if err != nil {
+			t.Errorf("%s: lookup failed: %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s = %v: expected %#v, got %#v", tt.path, tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestConvertValue_Errors(t *testing.T) {
+	tests := []struct {
+		path  string
+		value interface{}
+	}{
+		{"$.count", 3.5},
+		{"$.count", "3"},
+		{"$.small", 256},
+		{"$.small", -1},
+		{"$.label", 1},
+		{"$.tags", "a"},
+		{"$.tags[0]", 1},
+		{"$.limits", map[string]interface{}{"cpu": "high"}},
+		{"$.meta.a", 1},
+	}
+	for _, tt := range tests {
+		doc := convertDoc{Tags: []string{"x"}, Meta: map[string]string{"a": "b"}}
+		if res, err := JsonPathSet(doc, tt.path, tt.value); err == nil {
+			t.Errorf("%s = %v: expected error, got %v", tt.path, tt.value, res)
+		}
+	}
+}
+
+func TestConvertValue_MapElements(t *testing.T) {
+	doc := map[string]interface{}{
+		"counts": map[string]int{"a": 1},
+		"items":  []int{1, 2, 3},
+	}
+	res, err := JsonPathSet(doc, "$.counts.a", 2.0)
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if got := res.(map[string]interface{})["counts"]; !reflect.DeepEqual(got, map[string]int{"a": 2}) {
+		t.Errorf("expected map[a:2], got %#v", got)
+	}
+
+	res, err = JsonPathSet(doc, "$.items[1]", nil)
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if got := res.(map[string]interface{})["items"]; !reflect.DeepEqual(got, []int{1, 0, 3}) {
+		t.Errorf("expected [1 0 3], got %#v", got)
+	}
+
+	// nil keeps the key in generic maps
+	res, err = JsonPathSet(doc, "$.extra", nil)
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if v, ok := res.(map[string]interface{})["extra"]; !ok || v != nil {
+		t.Errorf("expected extra to be nil, got %v, %v", v, ok)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrCycle is returned under CycleError when a pointer graph refers back to
+// a value that is still being traversed.
+var ErrCycle = errors.New("cycle detected in object graph")
+
+// CyclePolicy decides what recursive descent, scans and the copy made by Set
+// do when they reach a value they are already inside of. Cycles are
+// detected by pointer identity of pointers, maps and slices.
+type CyclePolicy int
+
+const (
+	// CycleSkip skips revisited values. Copies keep the cycle by pointing
+	// back to the copy of the revisited value. This is the default.
+	CycleSkip CyclePolicy = iota
+	// CycleError stops the evaluation with ErrCycle.
+	CycleError
+)
+
+// WithCyclePolicy returns a copy of c that handles cycles with p.
+func (c *Compiled) WithCyclePolicy(p CyclePolicy) *Compiled {
+	res := *c
+	res.cycles = p
+	return &res
+}
+
+// visitKey identifies a reference value. The type is part of the key as a
+// struct and its first field share the same address.
+type visitKey struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+func refKey(v reflect.Value) (visitKey, bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map:
+		if v.IsNil() {
+			return visitKey{}, false
+		}
+		return visitKey{v.Type(), v.Pointer(), 0}, true
+	case reflect.Slice:
+		if v.IsNil() || v.Len() == 0 {
+			return visitKey{}, false
+		}
+		return visitKey{v.Type(), v.Pointer(), v.Len()}, true
+	}
+	return visitKey{}, false
+}
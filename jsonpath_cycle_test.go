@@ -0,0 +1,131 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type cycleNode struct {
+	Name     string
+	Parent   *cycleNode
+	Children []*cycleNode
+}
+
+func cycleTree() *cycleNode {
+	root := &cycleNode{Name: "root"}
+	a := &cycleNode{Name: "a", Parent: root}
+	b := &cycleNode{Name: "b", Parent: root}
+	root.Children = []*cycleNode{a, b}
+	return root
+}
+
+func TestCycle_DescendantsSkip(t *testing.T) {
+	res, err := JsonPathLookup(cycleTree(), "$..Name")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	names := []string{}
+	for _, n := range res.([]interface{}) {
+		names = append(names, n.(string))
+	}
+	sort.Strings(names)
+	expected := []string{"a", "b", "root"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestCycle_DescendantsError(t *testing.T) {
+	c := MustCompile("$..Name").WithCyclePolicy(CycleError)
+	if _, err := c.Lookup(cycleTree()); err != ErrCycle {
+		t.Errorf("expected ErrCycle, got %v", err)
+	}
+
+	// shared values that do not form a cycle are fine
+	shared := map[string]interface{}{"x": 1}
+	doc := map[string]interface{}{"a": shared, "b": shared}
+	res, err := MustCompile("$..x").WithCyclePolicy(CycleError).Lookup(doc)
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if len(res.([]interface{})) != 2 {
+		t.Errorf("expected 2 results, got %v", res)
+	}
+}
+
+func TestCycle_SelfReferencingMap(t *testing.T) {
+	m := map[string]interface{}{"name": "m"}
+	m["self"] = m
+	res, err := JsonPathLookup(m, "$..name")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if !reflect.DeepEqual(res, []interface{}{"m"}) {
+		t.Errorf("expected [m], got %v", res)
+	}
+
+	cp := deepCopy(m).(map[string]interface{})
+	if reflect.ValueOf(cp["self"]).Pointer() != reflect.ValueOf(cp).Pointer() {
+		t.Error("copy should refer to itself")
+	}
+}
+
+func TestCycle_Scan(t *testing.T) {
+	s := []interface{}{map[string]interface{}{"a": 1}, nil}
+	s[1] = s
+	res, err := get_scan(nil, s)
+	if err != nil {
+		t.Fatalf("get_scan failed: %v", err)
+	}
+	if !reflect.DeepEqual(res, []interface{}{1}) {
+		t.Errorf("expected [1], got %v", res)
+	}
+	if _, err := get_scan(&evaluator{cycles: CycleError}, s); err != ErrCycle {
+		t.Errorf("expected ErrCycle, got %v", err)
+	}
+}
+
+func TestCycle_SetCopiesGraph(t *testing.T) {
+	root := cycleTree()
+	res, err := JsonPathSet(*root, "$.Name", "new root")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if root.Name != "root" {
+		t.Error("original was modified")
+	}
+
+	copied := res.(cycleNode)
+	if copied.Name != "new root" {
+		t.Fatalf("unexpected copy %+v", copied)
+	}
+	child := copied.Children[0]
+	if child == root.Children[0] {
+		t.Error("children should be copied")
+	}
+	if child.Parent.Children[0] != child {
+		t.Error("copied parent should point back to the copied child")
+	}
+
+	_, err = MustCompile("$.Name").WithCyclePolicy(CycleError).Set(*root, "x")
+	if err != ErrCycle {
+		t.Errorf("expected ErrCycle, got %v", err)
+	}
+}
+
+func TestCycle_CopyNilValues(t *testing.T) {
+	m := map[string]interface{}{"a": nil, "b": 1}
+	if !reflect.DeepEqual(deepCopy(m), m) {
+		t.Errorf("nil map values should be kept, got %v", deepCopy(m))
+	}
+
+	n := cycleNode{Name: "leaf"}
+	if !reflect.DeepEqual(deepCopy(n), n) {
+		t.Errorf("nil pointer fields should be kept, got %v", deepCopy(n))
+	}
+}
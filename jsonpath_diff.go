@@ -0,0 +1,422 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind tells how a value differs between two documents.
+type ChangeKind int
+
+const (
+	// Added values are only in the new document.
+	Added ChangeKind = iota
+	// Removed values are only in the old document.
+	Removed
+	// Changed values are in both documents, but not equal.
+	Changed
+	// TypeChanged values are in both documents with different JSON types,
+	// like a string that became a number. Only DiffDocuments tells them
+	// from Changed values.
+	TypeChanged
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	case TypeChanged:
+		return "type changed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// MarshalText encodes k as its name, so changes encode to readable JSON.
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Change is a difference at the normalized path Path. Old is the value in
+// the old document and New the one in the new document, nil when the
+// value is missing there. Both are always encoded, so a null value that was
+// added or removed shows in JSON.
+type Change struct {
+	Kind ChangeKind  `json:"kind"`
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// QueryDiff is the changes to the nodes selected by the query with the
+// path Path.
+type QueryDiff struct {
+	Path    string   `json:"path"`
+	Changes []Change `json:"changes"`
+}
+
+// Diff evaluates every query against both versions of a document with
+// LookupNodes and returns, in the order of the queries, the nodes each one
+// selects in only one of them, and the nodes it selects in both whose
+// values are not equal. Values are compared as JSON, so 1 and 1.0 are
+// equal, and maps and structs are equal when they have the same members.
+// Removed and changed nodes come first in the order of old, then added
+// nodes in the order of new.
+func Diff(old, new interface{}, queries ...*Compiled) ([]QueryDiff, error) {
+	res := make([]QueryDiff, len(queries))
+	for i, c := range queries {
+		changes, err := c.diff(old, new)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.path, err)
+		}
+		res[i] = QueryDiff{Path: c.path, Changes: changes}
+	}
+	return res, nil
+}
+
+func (c *Compiled) diff(old, new interface{}) ([]Change, error) {
+	before, err := c.LookupNodes(old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := c.LookupNodes(new)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]interface{}, len(after))
+	for _, n := range after {
+		index[n.Path] = n.Value
+	}
+	changes := []Change{}
+	seen := make(map[string]bool, len(before))
+	for _, n := range before {
+		if seen[n.Path] {
+			// like $.a[0,0], a query may select a node twice
+			continue
+		}
+		seen[n.Path] = true
+		v, ok := index[n.Path]
+		if !ok {
+			changes = append(changes, Change{Kind: Removed, Path: n.Path, Old: n.Value})
+		} else if !equalValues(n.Value, v) {
+			changes = append(changes, Change{Kind: Changed, Path: n.Path, Old: n.Value, New: v})
+		}
+	}
+	for _, n := range after {
+		if !seen[n.Path] {
+			seen[n.Path] = true
+			changes = append(changes, Change{Kind: Added, Path: n.Path, New: n.Value})
+		}
+	}
+	return changes, nil
+}
+
+// DiffOptions configures DiffDocumentsWithOptions.
+type DiffOptions struct {
+	// Ignore are paths, like $..updatedAt, whose nodes in either document
+	// are left out of the comparison together with their descendants.
+	Ignore []string
+	// ArrayKeys match the elements of some arrays by a key member rather
+	// than by index.
+	ArrayKeys []ArrayKey
+}
+
+// ArrayKey matches the elements of the arrays selected by Path by the
+// value of their member Key, so that $.items reordered by id does not show
+// as changed with Path $.items and Key id.
+type ArrayKey struct {
+	Path string
+	Key  string
+}
+
+// DiffDocuments compares two documents and returns their differences,
+// each located by normalized path. See DiffDocumentsWithOptions.
+func DiffDocuments(a, b interface{}) ([]Change, error) {
+	return DiffDocumentsWithOptions(a, b, DiffOptions{})
+}
+
+// DiffDocumentsWithOptions compares the documents a and b and returns the
+// values added to b, removed from a, changed in type and changed in value.
+// Objects are compared by member, in key order, and arrays by index unless
+// one of opts.ArrayKeys selects them in a or b. Elements of those arrays
+// with the same key are compared wherever they are, and elements without
+// the key member, or with the key of an earlier element, are compared by
+// their order among themselves. Values are compared as JSON like Diff
+// does.
+//
+// Removed values are located in a, all others in b. Paths of opts.Ignore
+// and opts.ArrayKeys are looked up with LookupNodes, so filters apply to
+// each document on its own. ErrCycle is returned for documents referring
+// back to themselves.
+func DiffDocumentsWithOptions(a, b interface{}, opts DiffOptions) ([]Change, error) {
+	d := &documentDiff{
+		changes: []Change{},
+		ignoreA: map[string]bool{},
+		ignoreB: map[string]bool{},
+		keysA:   map[string]string{},
+		keysB:   map[string]string{},
+		onPathA: map[visitKey]bool{},
+		onPathB: map[visitKey]bool{},
+	}
+	for _, p := range opts.Ignore {
+		inA, inB, err := lookupPaths(p, a, b)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range inA {
+			d.ignoreA[path] = true
+		}
+		for _, path := range inB {
+			d.ignoreB[path] = true
+		}
+	}
+	for _, ak := range opts.ArrayKeys {
+		inA, inB, err := lookupPaths(ak.Path, a, b)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range inA {
+			d.keysA[path] = ak.Key
+		}
+		for _, path := range inB {
+			d.keysB[path] = ak.Key
+		}
+	}
+	root := []interface{}{}
+	if err := d.compare(locNode{root, a}, locNode{root, b}); err != nil {
+		return nil, err
+	}
+	return d.changes, nil
+}
+
+// lookupPaths returns the paths of the nodes jpath selects in a and b.
+func lookupPaths(jpath string, a, b interface{}) ([]string, []string, error) {
+	c, err := Compile(jpath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", jpath, err)
+	}
+	var paths [2][]string
+	for i, obj := range []interface{}{a, b} {
+		nodes, err := c.LookupNodes(obj)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", jpath, err)
+		}
+		for _, n := range nodes {
+			paths[i] = append(paths[i], n.Path)
+		}
+	}
+	return paths[0], paths[1], nil
+}
+
+// documentDiff is the state of DiffDocumentsWithOptions, which walks x in
+// a and y in b together.
+type documentDiff struct {
+	changes          []Change
+	ignoreA, ignoreB map[string]bool
+	// keysA and keysB are the key members of the arrays matched by key
+	keysA, keysB     map[string]string
+	onPathA, onPathB map[visitKey]bool
+}
+
+func (d *documentDiff) compare(x, y locNode) error {
+	xpath, ypath := normalizedPath(x.loc), normalizedPath(y.loc)
+	if d.ignoreA[xpath] || d.ignoreB[ypath] {
+		return nil
+	}
+	xv, yv := indirectValue(reflect.ValueOf(x.value)), indirectValue(reflect.ValueOf(y.value))
+	xt, yt := jsonType(xv), jsonType(yv)
+	if xt != yt {
+		d.changes = append(d.changes, Change{Kind: TypeChanged, Path: ypath, Old: x.value, New: y.value})
+		return nil
+	}
+	if xt != "array" && xt != "object" {
+		if !equalValue(xv, yv) {
+			d.changes = append(d.changes, Change{Kind: Changed, Path: ypath, Old: x.value, New: y.value})
+		}
+		return nil
+	}
+
+	for _, side := range []struct {
+		value  interface{}
+		onPath map[visitKey]bool
+	}{{x.value, d.onPathA}, {y.value, d.onPathB}} {
+		if key, ok := refKey(reflect.ValueOf(side.value)); ok {
+			if side.onPath[key] {
+				return ErrCycle
+			}
+			side.onPath[key] = true
+			defer delete(side.onPath, key)
+		}
+	}
+	if xt == "object" {
+		return d.compareObjects(x, y, xv, yv)
+	}
+	if key, ok := d.keysA[xpath]; ok {
+		return d.compareKeyed(x, y, xv, yv, key)
+	}
+	if key, ok := d.keysB[ypath]; ok {
+		return d.compareKeyed(x, y, xv, yv, key)
+	}
+	for i := 0; i < xv.Len() || i < yv.Len(); i++ {
+		var err error
+		switch {
+		case i >= yv.Len():
+			d.removed(x.child(i, xv.Index(i).Interface()))
+		case i >= xv.Len():
+			d.added(y.child(i, yv.Index(i).Interface()))
+		default:
+			err = d.compare(x.child(i, xv.Index(i).Interface()), y.child(i, yv.Index(i).Interface()))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *documentDiff) compareObjects(x, y locNode, xv, yv reflect.Value) error {
+	xe, _ := objectEntries(xv)
+	ye, _ := objectEntries(yv)
+	names := make([]string, 0, len(xe)+len(ye))
+	for name := range xe {
+		names = append(names, name)
+	}
+	for name := range ye {
+		if _, ok := xe[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		xm, inX := xe[name]
+		ym, inY := ye[name]
+		switch {
+		case !inY:
+			d.removed(x.child(name, xm.Interface()))
+		case !inX:
+			d.added(y.child(name, ym.Interface()))
+		default:
+			if err := d.compare(x.child(name, xm.Interface()), y.child(name, ym.Interface())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *documentDiff) compareKeyed(x, y locNode, xv, yv reflect.Value, key string) error {
+	xk, yk := elementKeys(xv, key), elementKeys(yv, key)
+	index := make(map[string]int, len(yk))
+	for j, k := range yk {
+		if k != "" {
+			index[k] = j
+		}
+	}
+	matched := make([]bool, len(yk))
+	var xrest, yrest []int
+	for i, k := range xk {
+		j, ok := index[k]
+		switch {
+		case k == "":
+			xrest = append(xrest, i)
+		case !ok:
+			d.removed(x.child(i, xv.Index(i).Interface()))
+		default:
+			matched[j] = true
+			if err := d.compare(x.child(i, xv.Index(i).Interface()), y.child(j, yv.Index(j).Interface())); err != nil {
+				return err
+			}
+		}
+	}
+	for j, k := range yk {
+		if k == "" {
+			yrest = append(yrest, j)
+		} else if !matched[j] {
+			d.added(y.child(j, yv.Index(j).Interface()))
+		}
+	}
+
+	// elements without a key are matched in order
+	for n := 0; n < len(xrest) || n < len(yrest); n++ {
+		var err error
+		switch {
+		case n >= len(yrest):
+			d.removed(x.child(xrest[n], xv.Index(xrest[n]).Interface()))
+		case n >= len(xrest):
+			d.added(y.child(yrest[n], yv.Index(yrest[n]).Interface()))
+		default:
+			err = d.compare(x.child(xrest[n], xv.Index(xrest[n]).Interface()), y.child(yrest[n], yv.Index(yrest[n]).Interface()))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *documentDiff) removed(x locNode) {
+	if path := normalizedPath(x.loc); !d.ignoreA[path] {
+		d.changes = append(d.changes, Change{Kind: Removed, Path: path, Old: x.value})
+	}
+}
+
+func (d *documentDiff) added(y locNode) {
+	if path := normalizedPath(y.loc); !d.ignoreB[path] {
+		d.changes = append(d.changes, Change{Kind: Added, Path: path, New: y.value})
+	}
+}
+
+// elementKeys returns the JSON encoding of the key member of each element
+// of the array v, or "" for elements without one or with the key of an
+// earlier element.
+func elementKeys(v reflect.Value, key string) []string {
+	keys := make([]string, v.Len())
+	seen := make(map[string]bool, len(keys))
+	for i := range keys {
+		entries, ok := objectEntries(indirectValue(v.Index(i)))
+		if !ok {
+			continue
+		}
+		member, ok := entries[key]
+		if !ok || !indirectValue(member).IsValid() {
+			continue
+		}
+		b, err := json.Marshal(member.Interface())
+		if err != nil || seen[string(b)] {
+			continue
+		}
+		seen[string(b)] = true
+		keys[i] = string(b)
+	}
+	return keys
+}
+
+// jsonType returns the JSON type of v, an indirect value.
+func jsonType(v reflect.Value) string {
+	if !v.IsValid() {
+		return "null"
+	}
+	if _, ok := jsonNumber(v); ok {
+		return "number"
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return v.Type().String()
+}
@@ -0,0 +1,268 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestDiff(t *testing.T) {
+	old := decodeJSON(t, `{
+		"version": 1,
+		"servers": [
+			{"name": "a", "port": 80, "tags": ["x"]},
+			{"name": "b", "port": 81},
+			{"name": "c", "port": 82}
+		],
+		"limits": {"cpu": 1, "mem": 512}
+	}`)
+	new := decodeJSON(t, `{
+		"version": 2,
+		"servers": [
+			{"name": "a", "port": 80.0, "tags": ["x"]},
+			{"name": "b", "port": 8081, "enabled": true}
+		],
+		"limits": {"mem": 512, "cpu": 1}
+	}`)
+
+	diffs, err := Diff(old, new,
+		MustCompile("$.servers[*].port"),
+		MustCompile("$.limits"),
+		MustCompile("$.servers[?(@.port > 100)].name"),
+		MustCompile("$..enabled"),
+		MustCompile("$.missing"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]Change{
+		{
+			{Kind: Changed, Path: "$.servers[1].port", Old: 81.0, New: 8081.0},
+			{Kind: Removed, Path: "$.servers[2].port", Old: 82.0},
+		},
+		{},
+		{
+			{Kind: Added, Path: "$.servers[1].name", New: "b"},
+		},
+		{
+			{Kind: Added, Path: "$.servers[1].enabled", New: true},
+		},
+		{},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d diffs, want %d", len(diffs), len(want))
+	}
+	for i, d := range diffs {
+		if !reflect.DeepEqual(d.Changes, want[i]) {
+			t.Errorf("%s:\n got  %+v\n want %+v", d.Path, d.Changes, want[i])
+		}
+	}
+}
+
+func TestDiff_Duplicates(t *testing.T) {
+	old := decodeJSON(t, `{"a": [1, 2]}`)
+	new := decodeJSON(t, `{"a": [3]}`)
+	diffs, err := Diff(old, new, MustCompile("$.a[0,0]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Kind: Changed, Path: "$.a[0]", Old: 1.0, New: 3.0},
+	}
+	if !reflect.DeepEqual(diffs[0].Changes, want) {
+		t.Errorf("got %+v, want %+v", diffs[0].Changes, want)
+	}
+}
+
+// A key applied to an array selects the key of every element, like in
+// Lookup.
+func TestDiff_KeyOnArray(t *testing.T) {
+	old := decodeJSON(t, `{"book": [{"author": "a"}, {"author": "b"}, {"author": "c"}]}`)
+	new := decodeJSON(t, `{"book": [{"author": "a"}, {"author": "x"}, {"title": "c"}]}`)
+	diffs, err := Diff(old, new, MustCompile("$.book.author"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Kind: Changed, Path: "$.book[1].author", Old: "b", New: "x"},
+		{Kind: Removed, Path: "$.book[2].author", Old: "c"},
+	}
+	if !reflect.DeepEqual(diffs[0].Changes, want) {
+		t.Errorf("got %+v, want %+v", diffs[0].Changes, want)
+	}
+}
+
+func TestDiff_JSON(t *testing.T) {
+	b, err := json.Marshal(Change{Kind: Removed, Path: "$.a", Old: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"kind":"removed","path":"$.a","old":1,"new":null}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+	b, err = json.Marshal(Change{Kind: Added, Path: "$.stock", New: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"kind":"added","path":"$.stock","old":null,"new":null}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	diffs, err := Diff(map[string]interface{}{"a": 1}, map[string]interface{}{"a": 2}, MustCompile("$.a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = json.Marshal(diffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"path":"$.a","changes":[{"kind":"changed","path":"$.a","old":1,"new":2}]}]`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestDiff_Errors(t *testing.T) {
+	_, err := Diff(json_data, json_data, MustCompile("$.store"), MustCompile("$.store.book.length()"))
+	if err == nil {
+		t.Errorf("expected an error for a function step")
+	}
+}
+
+func TestDiffDocuments(t *testing.T) {
+	a := decodeJSON(t, `{
+		"id": 7,
+		"name": "widget",
+		"price": 10,
+		"tags": ["a", "b"],
+		"size": "10",
+		"owner": {"name": "ann", "updatedAt": "2026-01-01"},
+		"updatedAt": "2026-01-01"
+	}`)
+	b := decodeJSON(t, `{
+		"id": 7.0,
+		"name": "gadget",
+		"tags": ["a", "c", "d"],
+		"size": 10,
+		"owner": {"name": "ann", "updatedAt": "2026-02-01"},
+		"stock": null,
+		"updatedAt": "2026-02-01"
+	}`)
+
+	got, err := DiffDocuments(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Kind: Changed, Path: "$.name", Old: "widget", New: "gadget"},
+		{Kind: Changed, Path: "$.owner.updatedAt", Old: "2026-01-01", New: "2026-02-01"},
+		{Kind: Removed, Path: "$.price", Old: 10.0},
+		{Kind: TypeChanged, Path: "$.size", Old: "10", New: 10.0},
+		{Kind: Added, Path: "$.stock"},
+		{Kind: Changed, Path: "$.tags[1]", Old: "b", New: "c"},
+		{Kind: Added, Path: "$.tags[2]", New: "d"},
+		{Kind: Changed, Path: "$.updatedAt", Old: "2026-01-01", New: "2026-02-01"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	got, err = DiffDocumentsWithOptions(a, b, DiffOptions{Ignore: []string{"$..updatedAt", "$.tags", "$.stock"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Change{
+		{Kind: Changed, Path: "$.name", Old: "widget", New: "gadget"},
+		{Kind: Removed, Path: "$.price", Old: 10.0},
+		{Kind: TypeChanged, Path: "$.size", Old: "10", New: 10.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ignoring:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	if got, err := DiffDocuments(a, a); err != nil || len(got) != 0 {
+		t.Errorf("same document: got %v, %v", got, err)
+	}
+}
+
+func TestDiffDocuments_ArrayKeys(t *testing.T) {
+	a := decodeJSON(t, `{"items": [
+		{"id": 1, "qty": 1},
+		{"id": 2, "qty": 2},
+		{"id": 3, "qty": 3},
+		{"qty": 9}
+	]}`)
+	b := decodeJSON(t, `{"items": [
+		{"id": 4, "qty": 4},
+		{"id": 3, "qty": 3},
+		{"qty": 8},
+		{"id": 1, "qty": 5}
+	]}`)
+
+	// by index every element differs
+	got, err := DiffDocuments(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 8 {
+		t.Errorf("by index: got %+v", got)
+	}
+
+	got, err = DiffDocumentsWithOptions(a, b, DiffOptions{
+		ArrayKeys: []ArrayKey{{Path: "$.items", Key: "id"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Kind: Changed, Path: "$.items[3].qty", Old: 1.0, New: 5.0},
+		{Kind: Removed, Path: "$.items[1]", Old: map[string]interface{}{"id": 2.0, "qty": 2.0}},
+		{Kind: Added, Path: "$.items[0]", New: map[string]interface{}{"id": 4.0, "qty": 4.0}},
+		{Kind: Changed, Path: "$.items[2].qty", Old: 9.0, New: 8.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("by key:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDiffDocuments_Structs(t *testing.T) {
+	type item struct {
+		ID   string `json:"id"`
+		Tags []string
+	}
+	a := []item{{"x", []string{"a"}}, {"y", nil}}
+	b := decodeJSON(t, `[{"id": "y", "Tags": null}, {"id": "x", "Tags": ["a"]}]`)
+	got, err := DiffDocumentsWithOptions(a, b, DiffOptions{ArrayKeys: []ArrayKey{{Path: "$", Key: "id"}}})
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %+v, %v", got, err)
+	}
+}
+
+func TestDiffDocuments_Errors(t *testing.T) {
+	cyclic := map[string]interface{}{}
+	cyclic["self"] = cyclic
+	if _, err := DiffDocuments(cyclic, cyclic); err != ErrCycle {
+		t.Errorf("got %v, want ErrCycle", err)
+	}
+	for _, opts := range []DiffOptions{
+		{Ignore: []string{"$.a["}},
+		{Ignore: []string{"$.store.length()"}},
+		{ArrayKeys: []ArrayKey{{Path: "$.a[", Key: "id"}}},
+	} {
+		if _, err := DiffDocumentsWithOptions(json_data, json_data, opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
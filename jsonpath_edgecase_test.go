@@ -8,6 +8,7 @@ package jsonpath
 
 import (
 	"encoding/json"
+	"errors"
 	"regexp"
 	"testing"
 )
@@ -93,3 +94,53 @@ func TestRegOp(t *testing.T) {
 		}
 	}
 }
+
+func TestErrNotFound(t *testing.T) {
+	doc := map[string]interface{}{"a": nil, "b": []interface{}{1}}
+	for _, p := range []string{"$.missing", "$.a.b", "$.b[3]", "$.b[-3]", "$.b[5:]"} {
+		_, err := JsonPathLookup(doc, p)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: %v should be ErrNotFound", p, err)
+		}
+	}
+	if !errors.Is(ErrGetFromNullObj, ErrNotFound) {
+		t.Errorf("ErrGetFromNullObj should be ErrNotFound")
+	}
+	if _, err := JsonPathLookup(doc, "$.b.*"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("unsupported operations are not ErrNotFound: %v", err)
+	}
+}
+
+func TestNullObj_IndexRangeFilter(t *testing.T) {
+	if _, err := get_idx(nil, 0); err != ErrGetFromNullObj {
+		t.Errorf("get_idx: expected ErrGetFromNullObj, got %v", err)
+	}
+	if _, err := get_range(nil, nil, 0, nil); err != ErrGetFromNullObj {
+		t.Errorf("get_range: expected ErrGetFromNullObj, got %v", err)
+	}
+	if _, err := get_filtered(nil, nil, nil, "@.a"); err != ErrGetFromNullObj {
+		t.Errorf("get_filtered: expected ErrGetFromNullObj, got %v", err)
+	}
+	if _, err := lookupIdx(traced(nil), 0); err != ErrGetFromNullObj {
+		t.Errorf("lookupIdx: expected ErrGetFromNullObj, got %v", err)
+	}
+	if _, err := lookupRange(nil, traced(nil), 0, nil); err != ErrGetFromNullObj {
+		t.Errorf("lookupRange: expected ErrGetFromNullObj, got %v", err)
+	}
+	if _, err := lookupFiltered(nil, traced(nil), nil, "@.a"); err != ErrGetFromNullObj {
+		t.Errorf("lookupFiltered: expected ErrGetFromNullObj, got %v", err)
+	}
+
+	doc := map[string]interface{}{"a": nil}
+	for _, p := range []string{"$.a[0]", "$.a[0,1]", "$.a[0:1]", "$.a[*]", "$.a[?(@.b)]"} {
+		if _, err := JsonPathLookup(doc, p); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: %v should be ErrNotFound", p, err)
+		}
+		if nodes, err := MustCompile(p).LookupNodes(doc); err != nil || len(nodes) != 0 {
+			t.Errorf("%s: expected no nodes, got %v, %v", p, nodes, err)
+		}
+	}
+	if _, err := JsonPathLookup(nil, "$[0]"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("$[0] on null: %v should be ErrNotFound", err)
+	}
+}
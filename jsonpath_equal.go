@@ -0,0 +1,124 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+)
+
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+// equalValues reports whether a and b encode to the same JSON value: numbers
+// are compared exactly whatever their Go type, maps and structs are compared
+// as objects by their JSON keys, and pointers by what they point to.
+func equalValues(a, b interface{}) bool {
+	return equalValue(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func equalValue(a, b reflect.Value) bool {
+	a, b = indirectValue(a), indirectValue(b)
+	if !a.IsValid() || !b.IsValid() {
+		return !a.IsValid() && !b.IsValid()
+	}
+
+	ra, aok := jsonNumber(a)
+	rb, bok := jsonNumber(b)
+	if aok || bok {
+		return aok && bok && ra.Cmp(rb) == 0
+	}
+
+	switch a.Kind() {
+	case reflect.String:
+		return b.Kind() == reflect.String && a.String() == b.String()
+	case reflect.Bool:
+		return b.Kind() == reflect.Bool && a.Bool() == b.Bool()
+	case reflect.Slice, reflect.Array:
+		if b.Kind() != reflect.Slice && b.Kind() != reflect.Array || a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValue(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map, reflect.Struct:
+		ea, aok := objectEntries(a)
+		eb, bok := objectEntries(b)
+		if !aok || !bok || len(ea) != len(eb) {
+			return false
+		}
+		for k, va := range ea {
+			vb, ok := eb[k]
+			if !ok || !equalValue(va, vb) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// jsonNumber returns the value of numbers, including json.Number and the
+// math/big types, but not of numeric strings.
+func jsonNumber(v reflect.Value) (*big.Rat, bool) {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil, false
+	}
+	if v.Kind() == reflect.String && v.Type() != jsonNumberType {
+		return nil, false
+	}
+	return numberRat(v.Interface())
+}
+
+// indirectValue unwraps interfaces and pointers up to a number or a non
+// pointer value. Like encoding/json it returns the zero Value, null, for
+// nil interfaces, pointers, maps and slices.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		if _, ok := jsonNumber(v); ok {
+			return v
+		}
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		return reflect.Value{}
+	}
+	return v
+}
+
+// objectEntries returns the members of a map or struct by their JSON key.
+// Fields promoted through nil embedded pointers
+// are left out.
+func objectEntries(v reflect.Value) (map[string]reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Map:
+		res := make(map[string]reflect.Value, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			name, err := mapKeyName(iter.Key())
+			if err != nil {
+				return nil, false
+			}
+			res[name] = iter.Value()
+		}
+		return res, true
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		res := make(map[string]reflect.Value, len(fields))
+		for _, f := range fields {
+			if fv, ok := fieldByIndex(v, f.index); ok {
+				res[f.name] = fv
+			}
+		}
+		return res, true
+	}
+	return nil, false
+}
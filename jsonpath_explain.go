@@ -0,0 +1,171 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Explanation is the trace of a lookup made by Explain. It renders as text
+// with String and as JSON with encoding/json.
+type Explanation struct {
+	Path  string      `json:"path"`
+	Steps []StepTrace `json:"steps"`
+	// Value is the result of the lookup and Error its error, if any.
+	Value interface{} `json:"value"`
+	Error string      `json:"error,omitempty"`
+}
+
+// StepTrace is a step of a lookup. In and Out are the number of nodes the
+// step was given and selected: a single value counts as one node, except
+// for the lists of values that steps like ranges, filters and recursive
+// descent select, and keys applied to an array, which select the key of
+// every element.
+type StepTrace struct {
+	// Op is the kind of step, such as key, idx, range, filter or recursive.
+	Op       string `json:"op"`
+	Selector string `json:"selector"`
+	In       int    `json:"in"`
+	Out      int    `json:"out"`
+	Error    string `json:"error,omitempty"`
+	// Filter holds the result of the predicate for each element of a
+	// filter step.
+	Filter []FilterTrace `json:"filter,omitempty"`
+}
+
+// FilterTrace is the result of a filter predicate for one element, located
+// relative to the filtered value as in @[0] or @.name. Error is the error
+// that stopped the filter, or the one a filter takes as a missing operand,
+// like the key error of @.isbn on a book without one, which makes the
+// predicate false.
+type FilterTrace struct {
+	Node  string `json:"node"`
+	Match bool   `json:"match"`
+	Error string `json:"error,omitempty"`
+}
+
+// Explain looks up the path in obj like Lookup and returns the trace of
+// every step, to tell which one lost the expected values. Steps after a
+// failing one are left out.
+func (c *Compiled) Explain(obj interface{}) *Explanation {
+	ev := c.newEvaluator(nil)
+	if ev == nil {
+		ev = &evaluator{limits: c.limits, cycles: c.cycles, mode: c.mode}
+	}
+	e := &Explanation{Path: c.path, Steps: []StepTrace{}}
+	t := traced(obj)
+	for i, s := range c.steps {
+		trace := StepTrace{Op: s.op, Selector: s.String(), In: t.count()}
+		ev.filtered = func(seg interface{}, ok bool, err error) {
+			f := FilterTrace{Node: "@" + strings.TrimPrefix(normalizedPath([]interface{}{seg}), "$"), Match: ok}
+			if err != nil {
+				f.Error = err.Error()
+			}
+			trace.Filter = append(trace.Filter, f)
+		}
+		res, err := c.explainStep(ev, t, s, i)
+		if err != nil {
+			trace.Error = err.Error()
+			e.Steps = append(e.Steps, trace)
+			e.Error = err.Error()
+			return e
+		}
+		t = res
+		trace.Out = t.count()
+		e.Steps = append(e.Steps, trace)
+	}
+	e.Value = t.value
+	return e
+}
+
+// explainStep is an iteration of lookup.
+func (c *Compiled) explainStep(ev *evaluator, t tracedValue, s step, i int) (tracedValue, error) {
+	if err := ev.tick(); err != nil {
+		return tracedValue{}, err
+	}
+	return lookupStep(ev, t, s, i+1 < len(c.steps) && c.steps[i+1].op == "key")
+}
+
+// String returns the step as written in a path. Indices, ranges and
+// filters without a key apply to the value itself, as in $[0].
+func (s step) String() string {
+	key := s.key
+	if key != "" && !isPlainKey(key) {
+		key = strconv.Quote(key)
+	}
+	if key != "" {
+		key = "." + key
+	}
+	switch s.op {
+	case "key":
+		return key
+	case "idx":
+		idx := []string{}
+		for _, i := range s.args.([]int) {
+			idx = append(idx, strconv.Itoa(i))
+		}
+		return key + "[" + strings.Join(idx, ",") + "]"
+	case "range":
+		args := s.args.([2]interface{})
+		if args[0] == nil && args[1] == nil {
+			return key + "[*]"
+		}
+		bound := func(b interface{}) string {
+			if b == nil {
+				return ""
+			}
+			return strconv.Itoa(b.(int))
+		}
+		return key + "[" + bound(args[0]) + ":" + bound(args[1]) + "]"
+	case "filter":
+		return key + "[?(" + s.args.(string) + ")]"
+	case "recursive":
+		return ".."
+	case "scan":
+		return ".*"
+	case "func":
+		return "." + s.key + "()"
+	case "member":
+		// index tokens select elements too
+		if _, ok := s.args.([]int); ok {
+			return "[" + s.key + "]"
+		}
+		return key
+	}
+	return s.op
+}
+
+// String renders the trace as text, a line for each step followed by the
+// results of filter predicates.
+func (e *Explanation) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "explain %s\n", e.Path)
+	for i, s := range e.Steps {
+		fmt.Fprintf(&sb, "%3d %-9s %-30s %d -> %d", i+1, s.Op, s.Selector, s.In, s.Out)
+		if s.Error != "" {
+			fmt.Fprintf(&sb, "  error: %s", s.Error)
+		}
+		sb.WriteString("\n")
+		for _, f := range s.Filter {
+			fmt.Fprintf(&sb, "      %-10s %t", f.Node, f.Match)
+			if f.Error != "" {
+				fmt.Fprintf(&sb, "  (%s)", f.Error)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if e.Error != "" {
+		fmt.Fprintf(&sb, "error: %s\n", e.Error)
+	} else {
+		out := 1
+		if len(e.Steps) > 0 {
+			out = e.Steps[len(e.Steps)-1].Out
+		}
+		fmt.Fprintf(&sb, "result: %d nodes\n", out)
+	}
+	return sb.String()
+}
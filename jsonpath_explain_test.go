@@ -0,0 +1,189 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		path  string
+		steps []StepTrace
+	}{
+		{"$.store.book[?(@.isbn)].title", []StepTrace{
+			{Op: "key", Selector: ".store", In: 1, Out: 1},
+			{Op: "filter", Selector: ".book[?(@.isbn)]", In: 1, Out: 2, Filter: []FilterTrace{
+				{Node: "@[0]", Error: "key error: isbn not found in object"},
+				{Node: "@[1]", Error: "key error: isbn not found in object"},
+				{Node: "@[2]", Match: true},
+				{Node: "@[3]", Match: true},
+			}},
+			{Op: "key", Selector: ".title", In: 2, Out: 2},
+		}},
+		{"$..price", []StepTrace{
+			{Op: "recursive", Selector: "..", In: 1, Out: 28},
+			{Op: "key", Selector: ".price", In: 28, Out: 5},
+		}},
+		{"$.store.book.author", []StepTrace{
+			{Op: "key", Selector: ".store", In: 1, Out: 1},
+			{Op: "key", Selector: ".book", In: 1, Out: 1},
+			{Op: "key", Selector: ".author", In: 1, Out: 4},
+		}},
+		{"$.store.book[0,-1].title", []StepTrace{
+			{Op: "key", Selector: ".store", In: 1, Out: 1},
+			{Op: "idx", Selector: ".book[0,-1]", In: 1, Out: 2},
+			{Op: "key", Selector: ".title", In: 2, Out: 2},
+		}},
+		{"$.store.book[1:].price", []StepTrace{
+			{Op: "key", Selector: ".store", In: 1, Out: 1},
+			{Op: "range", Selector: ".book[1:]", In: 1, Out: 3},
+			{Op: "key", Selector: ".price", In: 3, Out: 3},
+		}},
+		{"$.store.bicycle", []StepTrace{
+			{Op: "key", Selector: ".store", In: 1, Out: 1},
+			{Op: "key", Selector: ".bicycle", In: 1, Out: 1},
+		}},
+	}
+	for _, tt := range tests {
+		c := MustCompile(tt.path)
+		e := c.Explain(json_data)
+		if !reflect.DeepEqual(e.Steps, tt.steps) {
+			t.Errorf("%s:\n got  %+v\n want %+v", tt.path, e.Steps, tt.steps)
+		}
+		want, _ := c.Lookup(json_data)
+		if e.Error != "" || !reflect.DeepEqual(e.Value, want) {
+			t.Errorf("%s: got %v, %s, want %v", tt.path, e.Value, e.Error, want)
+		}
+	}
+}
+
+func TestExplain_RootAndMembers(t *testing.T) {
+	doc := []interface{}{map[string]interface{}{"a": []interface{}{1, 2}}}
+	e := MustCompile("$[0].a[*]").Explain(doc)
+	want := []StepTrace{
+		{Op: "idx", Selector: "[0]", In: 1, Out: 1},
+		{Op: "range", Selector: ".a[*]", In: 1, Out: 2},
+	}
+	if !reflect.DeepEqual(e.Steps, want) {
+		t.Errorf("got  %+v\nwant %+v", e.Steps, want)
+	}
+
+	c, err := FromPointer("/store/book/1/title")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = c.Explain(json_data)
+	want = []StepTrace{
+		{Op: "member", Selector: ".store", In: 1, Out: 1},
+		{Op: "member", Selector: ".book", In: 1, Out: 1},
+		{Op: "member", Selector: "[1]", In: 1, Out: 1},
+		{Op: "member", Selector: ".title", In: 1, Out: 1},
+	}
+	if e.Error != "" || e.Value != "Sword of Honour" {
+		t.Errorf("got %v, %q", e.Value, e.Error)
+	}
+	if !reflect.DeepEqual(e.Steps, want) {
+		t.Errorf("got  %+v\nwant %+v", e.Steps, want)
+	}
+}
+
+// Filters are evaluated once, by the lookup, so they count toward limits.
+func TestExplain_Limits(t *testing.T) {
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i}
+	}
+	c, _ := CompileWithLimits("$.items[?(@.id > 10)]", EvalLimits{MaxNodesVisited: 50})
+	e := c.Explain(map[string]interface{}{"items": items})
+	if !strings.Contains(e.Error, "MaxNodesVisited") {
+		t.Fatalf("expected MaxNodesVisited error, got %q", e.Error)
+	}
+	if n := len(e.Steps[0].Filter); n == 0 || n >= 50 {
+		t.Errorf("expected the filter to stop at the limit, got %d results", n)
+	}
+}
+
+func TestExplain_Error(t *testing.T) {
+	e := MustCompile("$.store.missing.price").Explain(json_data)
+	want := []StepTrace{
+		{Op: "key", Selector: ".store", In: 1, Out: 1},
+		{Op: "key", Selector: ".missing", In: 1, Error: "key error: missing not found in object"},
+	}
+	if !reflect.DeepEqual(e.Steps, want) {
+		t.Errorf("got  %+v\nwant %+v", e.Steps, want)
+	}
+	if e.Error != "key error: missing not found in object" || e.Value != nil {
+		t.Errorf("got %v, %q", e.Value, e.Error)
+	}
+
+	// an error stopping the filter is reported for the element causing it
+	e = MustCompile("$.store.book[?(@.title =~ /x/)]").Explain(map[string]interface{}{
+		"store": map[string]interface{}{"book": []interface{}{
+			map[string]interface{}{"title": 1},
+		}},
+	})
+	if e.Error == "" || len(e.Steps) != 2 || len(e.Steps[1].Filter) != 1 || e.Steps[1].Filter[0].Error != e.Error {
+		t.Errorf("unexpected trace %+v", e)
+	}
+}
+
+func TestExplain_Render(t *testing.T) {
+	e := MustCompile("$.store.book[?(@.isbn)].title").Explain(json_data)
+	text := e.String()
+	for _, line := range []string{
+		"explain $.store.book[?(@.isbn)].title",
+		"  2 filter    .book[?(@.isbn)]               1 -> 2",
+		"      @[0]       false  (key error: isbn not found in object)",
+		"      @[2]       true",
+		"result: 2 nodes",
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("missing line %q in\n%s", line, text)
+		}
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Explanation
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back.Steps, e.Steps) || back.Path != e.Path {
+		t.Errorf("JSON round trip: got %+v, want %+v", back, e)
+	}
+}
+
+func TestStepString(t *testing.T) {
+	for _, p := range []string{
+		"$.a.b",
+		"$.a[0,1]",
+		"$[0]",
+		"$[*]",
+		"$[?(@.b > 1)].c",
+		"$.a[1:3]",
+		"$.a[:-1]",
+		"$.a[*]",
+		"$.a[?(@.b > 1)]",
+		"$..b",
+		"$.a.*",
+		"$.a.length()",
+	} {
+		c := MustCompile(p)
+		s := "$"
+		for _, st := range c.steps {
+			s += st.String()
+		}
+		s = strings.Replace(s, "$...", "$..", 1)
+		if s != p {
+			t.Errorf("got %s, want %s", s, p)
+		}
+	}
+}
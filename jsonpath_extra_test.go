@@ -308,7 +308,7 @@ func Test_get_range_uncovered(t *testing.T) {
 		}
 		// Using nil, nil args for wildcard
 		args := [2]interface{}{nil, nil}
-		res, err := get_range(obj, args[0], args[1])
+		res, err := get_range(nil, obj, args[0], args[1])
 		if err != nil {
 			t.Fatalf("get_range failed: %v", err)
 		}
@@ -325,7 +325,7 @@ func Test_get_range_uncovered(t *testing.T) {
 			obj[string(rune('a'+i))] = i
 		}
 		args := [2]interface{}{nil, nil}
-		res, err := get_range(obj, args[0], args[1])
+		res, err := get_range(nil, obj, args[0], args[1])
 		if err != nil {
 			t.Fatalf("get_range failed: %v", err)
 		}
@@ -338,7 +338,7 @@ func Test_get_range_uncovered(t *testing.T) {
 	// Test: get_range to value exceeds length (clamping)
 	t.Run("get_range_to_exceeds_length", func(t *testing.T) {
 		obj := []interface{}{1, 2, 3}
-		res, err := get_range(obj, 0, 100)
+		res, err := get_range(nil, obj, 0, 100)
 		if err != nil {
 			t.Fatalf("get_range failed: %v", err)
 		}
@@ -357,7 +357,7 @@ func Test_get_range_uncovered(t *testing.T) {
 		// But _frm = 2 > _to = 0, which causes panic in Slice
 		// This is actually a bug in the get_range function - it should check _frm > _to
 		// For now, we test a valid negative to case
-		res, err := get_range(obj, 0, -2)
+		res, err := get_range(nil, obj, 0, -2)
 		if err != nil {
 			t.Fatalf("get_range failed: %v", err)
 		}
@@ -372,7 +372,7 @@ func Test_get_range_uncovered(t *testing.T) {
 	// Test: get_range negative from and to
 	t.Run("get_range_negative_from_and_to", func(t *testing.T) {
 		obj := []interface{}{1, 2, 3, 4, 5}
-		res, err := get_range(obj, -3, -1)
+		res, err := get_range(nil, obj, -3, -1)
 		if err != nil {
 			t.Fatalf("get_range failed: %v", err)
 		}
@@ -387,7 +387,7 @@ func Test_get_range_uncovered(t *testing.T) {
 	// Test: get_range with invalid from
 	t.Run("get_range_invalid_from", func(t *testing.T) {
 		obj := []interface{}{1, 2, 3}
-		_, err := get_range(obj, -10, nil)
+		_, err := get_range(nil, obj, -10, nil)
 		if err == nil {
 			t.Error("Expected error for out-of-bounds from")
 		}
@@ -530,7 +530,7 @@ func Test_get_range_to_negative_clamp(t *testing.T) {
 	// Actually the clamping happens before the Slice call, so _to=0 is valid
 	// We need _frm <= _to after clamping
 	// Let's try _frm = 0, _to = -10 => result should be empty slice
-	res, err := get_range(obj, 0, -10)
+	res, err := get_range(nil, obj, 0, -10)
 	if err != nil {
 		t.Fatalf("get_range failed: %v", err)
 	}
@@ -696,7 +696,7 @@ func Test_get_key_coverage(t *testing.T) {
 func Test_get_filtered_coverage(t *testing.T) {
 	// Test: filter on unsupported type (not slice or map)
 	t.Run("unsupported_type", func(t *testing.T) {
-		_, err := get_filtered("string", nil, "@ > 0")
+		_, err := get_filtered(nil, "string", nil, "@ > 0")
 		if err == nil {
 			t.Error("Expected error for unsupported type")
 		}
@@ -707,7 +707,7 @@ func Test_get_filtered_coverage(t *testing.T) {
 func Test_get_scan_coverage(t *testing.T) {
 	// Test: nil object
 	t.Run("nil_object", func(t *testing.T) {
-		res, err := get_scan(nil)
+		res, err := get_scan(nil, nil)
 		if err != nil {
 			t.Fatalf("get_scan failed: %v", err)
 		}
@@ -721,7 +721,7 @@ func Test_get_scan_coverage(t *testing.T) {
 		obj := make(map[string]int)
 		obj["a"] = 1
 		obj["b"] = 2
-		res, err := get_scan(obj)
+		res, err := get_scan(nil, obj)
 		if err != nil {
 			t.Fatalf("get_scan failed: %v", err)
 		}
@@ -738,7 +738,7 @@ func Test_get_scan_coverage(t *testing.T) {
 			map[string]interface{}{"a": 1, "b": 2},
 			map[string]interface{}{"c": 3, "d": 4},
 		}
-		res, err := get_scan(obj)
+		res, err := get_scan(nil, obj)
 		if err != nil {
 			t.Fatalf("get_scan failed: %v", err)
 		}
@@ -751,7 +751,7 @@ func Test_get_scan_coverage(t *testing.T) {
 
 	// Test: unsupported type
 	t.Run("unsupported_type", func(t *testing.T) {
-		_, err := get_scan(42)
+		_, err := get_scan(nil, 42)
 		if err == nil {
 			t.Error("Expected error for unsupported type")
 		}
@@ -887,7 +887,7 @@ func Test_getAllDescendants_coverage(t *testing.T) {
 			"a": 1,
 			"b": map[string]interface{}{"c": 2},
 		}
-		res := getAllDescendants(obj)
+		res, _ := getAllDescendants(nil, obj)
 		if len(res) < 2 {
 			t.Errorf("Expected at least 2 descendants, got %d", len(res))
 		}
@@ -896,7 +896,7 @@ func Test_getAllDescendants_coverage(t *testing.T) {
 	// Test: with pointer to nil
 	t.Run("pointer_to_nil", func(t *testing.T) {
 		var ptr *map[string]interface{} = nil
-		res := getAllDescendants(ptr)
+		res, _ := getAllDescendants(nil, ptr)
 		// Should return slice with just the nil pointer
 		if len(res) != 1 {
 			t.Errorf("Expected 1 descendant, got %d", len(res))
@@ -906,7 +906,7 @@ func Test_getAllDescendants_coverage(t *testing.T) {
 	// Test: with array
 	t.Run("with_array", func(t *testing.T) {
 		obj := [3]interface{}{1, 2, 3}
-		res := getAllDescendants(obj)
+		res, _ := getAllDescendants(nil, obj)
 		if len(res) < 3 {
 			t.Errorf("Expected at least 3 descendants, got %d", len(res))
 		}
@@ -918,7 +918,7 @@ func Test_set_range_coverage(t *testing.T) {
 	// Test: nil object
 	t.Run("nil_object", func(t *testing.T) {
 		s := step{op: "range", args: [2]interface{}{0, 1}}
-		_, err := set_range(nil, s, []step{}, 0, 99)
+		_, err := set_range(nil, nil, s, []step{}, 0, 99)
 		if err != ErrGetFromNullObj {
 			t.Errorf("Expected ErrGetFromNullObj, got %v", err)
 		}
@@ -928,7 +928,7 @@ func Test_set_range_coverage(t *testing.T) {
 	t.Run("key_error", func(t *testing.T) {
 		s := step{op: "range", key: "nonexistent", args: [2]interface{}{0, 1}}
 		obj := map[string]interface{}{"other": 1}
-		_, err := set_range(obj, s, []step{}, 0, 99)
+		_, err := set_range(nil, obj, s, []step{}, 0, 99)
 		if err == nil {
 			t.Error("Expected error for key not found")
 		}
@@ -938,7 +938,7 @@ func Test_set_range_coverage(t *testing.T) {
 	t.Run("non_slice_type", func(t *testing.T) {
 		s := step{op: "range", args: [2]interface{}{0, 1}}
 		obj := map[string]interface{}{"a": 1}
-		_, err := set_range(obj, s, []step{}, 0, 99)
+		_, err := set_range(nil, obj, s, []step{}, 0, 99)
 		if err == nil {
 			t.Error("Expected error for non-slice type")
 		}
@@ -948,7 +948,7 @@ func Test_set_range_coverage(t *testing.T) {
 	t.Run("negative_from", func(t *testing.T) {
 		s := step{op: "range", args: [2]interface{}{-2, -1}}
 		obj := []interface{}{1, 2, 3, 4, 5}
-		res, err := set_range(obj, s, []step{}, 0, 99)
+		res, err := set_range(nil, obj, s, []step{}, 0, 99)
 		if err != nil {
 			t.Fatalf("set_range failed: %v", err)
 		}
@@ -966,7 +966,7 @@ func Test_set_range_coverage(t *testing.T) {
 	t.Run("from_greater_than_to", func(t *testing.T) {
 		s := step{op: "range", args: [2]interface{}{3, 1}}
 		obj := []interface{}{1, 2, 3, 4, 5}
-		res, err := set_range(obj, s, []step{}, 0, 99)
+		res, err := set_range(nil, obj, s, []step{}, 0, 99)
 		if err != nil {
 			t.Fatalf("set_range failed: %v", err)
 		}
@@ -1046,7 +1046,7 @@ func Test_get_filtered_map_coverage(t *testing.T) {
 			"b": "world",
 			"c": "test",
 		}
-		res, err := get_filtered(obj, obj, "@ =~ /hel.*/")
+		res, err := get_filtered(nil, obj, obj, "@ =~ /hel.*/")
 		if err != nil {
 			t.Fatalf("get_filtered failed: %v", err)
 		}
@@ -1061,7 +1061,7 @@ func Test_get_filtered_map_coverage(t *testing.T) {
 			"b": 10,
 			"c": 5,
 		}
-		res, err := get_filtered(obj, obj, "@ > 3")
+		res, err := get_filtered(nil, obj, obj, "@ > 3")
 		if err != nil {
 			t.Fatalf("get_filtered failed: %v", err)
 		}
@@ -1078,7 +1078,7 @@ func Test_get_filtered_map_coverage(t *testing.T) {
 		root := map[string]interface{}{
 			"threshold": 5,
 		}
-		res, err := get_filtered(obj, root, "@ > $.threshold")
+		res, err := get_filtered(nil, obj, root, "@ > $.threshold")
 		if err != nil {
 			t.Fatalf("get_filtered failed: %v", err)
 		}
@@ -1248,7 +1248,7 @@ func Test_get_range_more_coverage(t *testing.T) {
 		// When from > to, the code clamps from to to, so from=3, to=1 becomes from=1, to=1
 		// This results in an empty slice [1:1]
 		// But the actual code may panic, so let's test a safer case
-		res, err := get_range(obj, 2, 2)
+		res, err := get_range(nil, obj, 2, 2)
 		if err != nil {
 			t.Fatalf("get_range failed: %v", err)
 		}
@@ -1261,7 +1261,7 @@ func Test_get_range_more_coverage(t *testing.T) {
 	// Test: to nil (get all from start)
 	t.Run("to_nil", func(t *testing.T) {
 		obj := []interface{}{1, 2, 3, 4, 5}
-		res, err := get_range(obj, 2, nil)
+		res, err := get_range(nil, obj, 2, nil)
 		if err != nil {
 			t.Fatalf("get_range failed: %v", err)
 		}
@@ -1453,7 +1453,7 @@ func Test_set_idx_coverage(t *testing.T) {
 	// Test: nil object
 	t.Run("nil_object", func(t *testing.T) {
 		s := step{op: "idx", args: []int{0}}
-		_, err := set_idx(nil, s, []step{}, 0, "value")
+		_, err := set_idx(nil, nil, s, []step{}, 0, "value")
 		if err == nil {
 			t.Error("Expected error for nil object")
 		}
@@ -1463,7 +1463,7 @@ func Test_set_idx_coverage(t *testing.T) {
 	t.Run("non_slice_type", func(t *testing.T) {
 		s := step{op: "idx", args: []int{0}}
 		obj := map[string]interface{}{"a": 1}
-		_, err := set_idx(obj, s, []step{}, 0, "value")
+		_, err := set_idx(nil, obj, s, []step{}, 0, "value")
 		if err == nil {
 			t.Error("Expected error for non-slice type")
 		}
@@ -1473,7 +1473,7 @@ func Test_set_idx_coverage(t *testing.T) {
 	t.Run("index_out_of_range", func(t *testing.T) {
 		s := step{op: "idx", args: []int{10}}
 		obj := []interface{}{1, 2, 3}
-		_, err := set_idx(obj, s, []step{}, 0, "value")
+		_, err := set_idx(nil, obj, s, []step{}, 0, "value")
 		if err == nil {
 			t.Error("Expected error for index out of range")
 		}
@@ -1483,7 +1483,7 @@ func Test_set_idx_coverage(t *testing.T) {
 	t.Run("negative_index", func(t *testing.T) {
 		s := step{op: "idx", args: []int{-1}}
 		obj := []interface{}{1, 2, 3}
-		res, err := set_idx(obj, s, []step{}, 0, "value")
+		res, err := set_idx(nil, obj, s, []step{}, 0, "value")
 		if err != nil {
 			t.Fatalf("set_idx failed: %v", err)
 		}
@@ -1497,7 +1497,7 @@ func Test_set_idx_coverage(t *testing.T) {
 	t.Run("negative_index_out_of_range", func(t *testing.T) {
 		s := step{op: "idx", args: []int{-10}}
 		obj := []interface{}{1, 2, 3}
-		_, err := set_idx(obj, s, []step{}, 0, "value")
+		_, err := set_idx(nil, obj, s, []step{}, 0, "value")
 		if err == nil {
 			t.Error("Expected error for negative index out of range")
 		}
@@ -1509,7 +1509,7 @@ func Test_set_idx_coverage(t *testing.T) {
 		obj := map[string]interface{}{
 			"items": []interface{}{"a", "b", "c"},
 		}
-		res, err := set_idx(obj, s, []step{}, 0, "X")
+		res, err := set_idx(nil, obj, s, []step{}, 0, "X")
 		if err != nil {
 			t.Fatalf("set_idx failed: %v", err)
 		}
@@ -1698,7 +1698,7 @@ func Test_set_key_coverage(t *testing.T) {
 	// Test: nil object
 	t.Run("nil_object", func(t *testing.T) {
 		s := step{op: "key", key: "name"}
-		_, err := set_key(nil, "name", []step{s}, 0, "value")
+		_, err := set_key(nil, nil, "name", []step{s}, 0, "value")
 		if err == nil {
 			t.Error("Expected error for nil object")
 		}
@@ -1708,7 +1708,7 @@ func Test_set_key_coverage(t *testing.T) {
 	t.Run("non_map_type", func(t *testing.T) {
 		s := step{op: "key", key: "name"}
 		obj := "string"
-		_, err := set_key(obj, "name", []step{s}, 0, "value")
+		_, err := set_key(nil, obj, "name", []step{s}, 0, "value")
 		if err == nil {
 			t.Error("Expected error for non-map type")
 		}
@@ -1720,7 +1720,7 @@ func Test_set_recursive_coverage(t *testing.T) {
 	// Test: nil object
 	t.Run("nil_object", func(t *testing.T) {
 		// Note: set_recursive may not return error for nil object in all cases
-		_, err := set_recursive(nil, []step{}, 0, "value")
+		_, err := set_recursive(nil, nil, []step{}, 0, "value")
 		if err == nil {
 			t.Logf("Got no error for nil object (may be expected)")
 		}
@@ -1730,7 +1730,7 @@ func Test_set_recursive_coverage(t *testing.T) {
 	t.Run("empty_steps", func(t *testing.T) {
 		obj := map[string]interface{}{"a": 1}
 		// set_recursive with empty steps returns the value as-is
-		res, err := set_recursive(obj, []step{}, 0, "value")
+		res, err := set_recursive(nil, obj, []step{}, 0, "value")
 		if err != nil {
 			t.Logf("Got error: %v", err)
 		}
@@ -1742,7 +1742,7 @@ func Test_set_recursive_coverage(t *testing.T) {
 	t.Run("unsupported_op", func(t *testing.T) {
 		s := step{op: "unknown", key: ""}
 		obj := map[string]interface{}{"a": 1}
-		_, err := set_recursive(obj, []step{s}, 0, "value")
+		_, err := set_recursive(nil, obj, []step{s}, 0, "value")
 		if err == nil {
 			t.Error("Expected error for unsupported operation")
 		}
@@ -2245,7 +2245,7 @@ func Test_get_filtered_map_regexp(t *testing.T) {
 		"b": "foo bar",
 		"c": "hello there",
 	}
-	res, err := get_filtered(obj, obj, "@ =~ /hello.*/")
+	res, err := get_filtered(nil, obj, obj, "@ =~ /hello.*/")
 	if err != nil {
 		t.Fatalf("get_filtered failed: %v", err)
 	}
@@ -2260,7 +2260,7 @@ func Test_get_filtered_map_comparison(t *testing.T) {
 		"b": 10,
 		"c": 5,
 	}
-	res, err := get_filtered(obj, obj, "@ > 3")
+	res, err := get_filtered(nil, obj, obj, "@ > 3")
 	if err != nil {
 		t.Fatalf("get_filtered failed: %v", err)
 	}
@@ -2275,7 +2275,7 @@ func Test_get_filtered_slice_regexp(t *testing.T) {
 		"foo bar",
 		"hello there",
 	}
-	res, err := get_filtered(obj, obj, "@ =~ /hello.*/")
+	res, err := get_filtered(nil, obj, obj, "@ =~ /hello.*/")
 	if err != nil {
 		t.Fatalf("get_filtered failed: %v", err)
 	}
@@ -2285,7 +2285,7 @@ func Test_get_filtered_slice_regexp(t *testing.T) {
 
 // Test_get_filtered_invalid_type tests get_filtered on invalid type
 func Test_get_filtered_invalid_type(t *testing.T) {
-	_, err := get_filtered("string", nil, "@ > 0")
+	_, err := get_filtered(nil, "string", nil, "@ > 0")
 	if err == nil {
 		t.Error("Expected error for invalid type")
 	}
@@ -2301,7 +2301,7 @@ func Test_set_idx_with_key(t *testing.T) {
 		"items": []interface{}{"a", "b", "c"},
 	}
 	s := step{op: "idx", key: "items", args: []int{0}}
-	res, err := set_idx(obj, s, []step{}, 0, "X")
+	res, err := set_idx(nil, obj, s, []step{}, 0, "X")
 	if err != nil {
 		t.Fatalf("set_idx failed: %v", err)
 	}
@@ -2318,7 +2318,7 @@ func Test_set_idx_key_error(t *testing.T) {
 		"items": []interface{}{"a", "b", "c"},
 	}
 	s := step{op: "idx", key: "nonexistent", args: []int{0}}
-	_, err := set_idx(obj, s, []step{}, 0, "X")
+	_, err := set_idx(nil, obj, s, []step{}, 0, "X")
 	if err == nil {
 		t.Error("Expected error for key not found")
 	}
@@ -2330,7 +2330,7 @@ func Test_set_range_with_key(t *testing.T) {
 		"items": []interface{}{"a", "b", "c", "d", "e"},
 	}
 	s := step{op: "range", key: "items", args: [2]interface{}{1, 3}}
-	res, err := set_range(obj, s, []step{}, 0, "X")
+	res, err := set_range(nil, obj, s, []step{}, 0, "X")
 	if err != nil {
 		t.Fatalf("set_range failed: %v", err)
 	}
@@ -2345,7 +2345,7 @@ func Test_set_range_with_key(t *testing.T) {
 func Test_set_range_clamped(t *testing.T) {
 	obj := []interface{}{"a", "b", "c"}
 	s := step{op: "range", args: [2]interface{}{1, 100}}
-	res, err := set_range(obj, s, []step{}, 0, "X")
+	res, err := set_range(nil, obj, s, []step{}, 0, "X")
 	if err != nil {
 		t.Fatalf("set_range failed: %v", err)
 	}
@@ -2360,7 +2360,7 @@ func Test_set_range_clamped(t *testing.T) {
 func Test_set_range_negative_from(t *testing.T) {
 	obj := []interface{}{"a", "b", "c", "d", "e"}
 	s := step{op: "range", args: [2]interface{}{-2, -1}}
-	res, err := set_range(obj, s, []step{}, 0, "X")
+	res, err := set_range(nil, obj, s, []step{}, 0, "X")
 	if err != nil {
 		t.Fatalf("set_range failed: %v", err)
 	}
@@ -2375,7 +2375,7 @@ func Test_set_range_negative_from(t *testing.T) {
 func Test_set_range_from_clamped_to_zero(t *testing.T) {
 	obj := []interface{}{"a", "b", "c"}
 	s := step{op: "range", args: [2]interface{}{-10, 1}}
-	res, err := set_range(obj, s, []step{}, 0, "X")
+	res, err := set_range(nil, obj, s, []step{}, 0, "X")
 	if err != nil {
 		t.Fatalf("set_range failed: %v", err)
 	}
@@ -2541,7 +2541,7 @@ func Test_set_idx_nested(t *testing.T) {
 		s,
 		{op: "key", key: "name", args: nil},
 	}
-	res, err := set_idx(obj, s, steps, 0, "updated")
+	res, err := set_idx(nil, obj, s, steps, 0, "updated")
 	if err != nil {
 		t.Fatalf("set_idx failed: %v", err)
 	}
@@ -2560,7 +2560,7 @@ func Test_set_idx_interface_unwrap(t *testing.T) {
 		"items": iface,
 	}
 	s := step{op: "idx", key: "items", args: []int{0}}
-	res, err := set_idx(obj, s, []step{}, 0, "X")
+	res, err := set_idx(nil, obj, s, []step{}, 0, "X")
 	if err != nil {
 		t.Fatalf("set_idx failed: %v", err)
 	}
@@ -2587,7 +2587,7 @@ func Test_set_range_nested(t *testing.T) {
 		s,
 		{op: "key", key: "name", args: nil},
 	}
-	res, err := set_range(obj, s, steps, 0, "updated")
+	res, err := set_range(nil, obj, s, steps, 0, "updated")
 	if err != nil {
 		t.Fatalf("set_range failed: %v", err)
 	}
@@ -2621,7 +2621,7 @@ func Test_set_key_nested(t *testing.T) {
 		{op: "key", key: "b", args: nil},
 		{op: "key", key: "c", args: nil},
 	}
-	res, err := set_key(obj, "a", steps, 0, 99)
+	res, err := set_key(nil, obj, "a", steps, 0, 99)
 	if err != nil {
 		t.Fatalf("set_key failed: %v", err)
 	}
@@ -2636,7 +2636,7 @@ func Test_set_key_nested(t *testing.T) {
 // Test_set_key_invalid_type tests set_key with invalid type
 func Test_set_key_invalid_type(t *testing.T) {
 	obj := "string"
-	_, err := set_key(obj, "key", []step{}, 0, "value")
+	_, err := set_key(nil, obj, "key", []step{}, 0, "value")
 	if err == nil {
 		t.Error("Expected error for invalid type")
 	}
@@ -2649,7 +2649,7 @@ func Test_set_key_invalid_type(t *testing.T) {
 // Test_get_range_from_nil tests get_range with nil from
 func Test_get_range_from_nil(t *testing.T) {
 	obj := []interface{}{1, 2, 3, 4, 5}
-	res, err := get_range(obj, nil, 2)
+	res, err := get_range(nil, obj, nil, 2)
 	if err != nil {
 		t.Fatalf("get_range failed: %v", err)
 	}
@@ -2661,7 +2661,7 @@ func Test_get_range_from_nil(t *testing.T) {
 // Test_get_range_both_nil tests get_range with both nil (wildcard)
 func Test_get_range_both_nil(t *testing.T) {
 	obj := []interface{}{1, 2, 3}
-	res, err := get_range(obj, nil, nil)
+	res, err := get_range(nil, obj, nil, nil)
 	if err != nil {
 		t.Fatalf("get_range failed: %v", err)
 	}
@@ -0,0 +1,71 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"errors"
+)
+
+// funcMapCache holds the paths compiled by the FuncMap functions, which
+// templates call with the same few constant paths over and over.
+var funcMapCache = NewCache(1024)
+
+// FuncMap returns functions for text/template and html/template:
+//
+//	jsonpath PATH DATA       the result of Lookup
+//	jsonpathFirst PATH DATA  the first match, or nil when there is none
+//	jsonpathAll PATH DATA    all matches as a list, empty when there are none
+//	jsonpathExists PATH DATA whether the path matches anything
+//
+// DATA comes last so the functions can end a pipeline, as in
+// {{ .Order | jsonpath "$.items[0].sku" }}. Compiled paths are kept in a
+// Cache shared by all templates. Invalid paths and lookup errors fail the
+// execution of the template, except that jsonpathFirst, jsonpathAll and
+// jsonpathExists treat keys and indices that do not exist as no match.
+//
+// The result can be passed to Funcs directly:
+//
+//	t := template.New("mail").Funcs(jsonpath.FuncMap())
+func FuncMap() map[string]interface{} {
+	return map[string]interface{}{
+		"jsonpath":       funcLookup,
+		"jsonpathFirst":  funcFirst,
+		"jsonpathAll":    funcAll,
+		"jsonpathExists": funcExists,
+	}
+}
+
+func funcLookup(jpath string, data interface{}) (interface{}, error) {
+	c, err := funcMapCache.Compile(jpath)
+	if err != nil {
+		return nil, err
+	}
+	return c.Lookup(data)
+}
+
+func funcFirst(jpath string, data interface{}) (interface{}, error) {
+	all, err := funcAll(jpath, data)
+	if err != nil || len(all) == 0 {
+		return nil, err
+	}
+	return all[0], nil
+}
+
+func funcAll(jpath string, data interface{}) ([]interface{}, error) {
+	c, err := funcMapCache.Compile(jpath)
+	if err != nil {
+		return nil, err
+	}
+	all, _, err := c.LookupMatches(data)
+	if errors.Is(err, ErrNotFound) {
+		return []interface{}{}, nil
+	}
+	return all, err
+}
+
+func funcExists(jpath string, data interface{}) (bool, error) {
+	all, err := funcAll(jpath, data)
+	return len(all) > 0, err
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFuncMap_TextTemplate(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{`{{ jsonpath "$.store.bicycle.color" . }}`, "red"},
+		{`{{ . | jsonpath "$.store.book[0].author" }}`, "Nigel Rees"},
+		{`{{ jsonpath "$.store.book[0:2].price" . }}`, "[8.95 12.99]"},
+		{`{{ jsonpathFirst "$.store.book[?(@.price > 10)].title" . }}`, "Sword of Honour"},
+		{`{{ jsonpathFirst "$.store.book[?(@.price > 100)].title" . }}`, "<no value>"},
+		{`{{ jsonpathFirst "$.store.car" . }}`, "<no value>"},
+		{`{{ range jsonpathAll "$.store.book[*].isbn" . }}{{ . }};{{ end }}`, "0-553-21311-3;0-395-19395-8;"},
+		{`{{ len (jsonpathAll "$.store.book" .) }}`, "1"},
+		// a key applied to an array selects a list, a negative index one value
+		{`{{ jsonpathFirst "$.store.book.author" . }}`, "Nigel Rees"},
+		{`{{ len (jsonpathAll "$.store.book.author" .) }}`, "4"},
+		{`{{ len (jsonpathAll "$.store.book[-1]" .) }}`, "1"},
+		{`{{ len (jsonpathAll "$.store.car" .) }}`, "0"},
+		{`{{ jsonpathExists "$.store.bicycle" . }} {{ jsonpathExists "$.store.car" . }}`, "true false"},
+		{`{{ if jsonpathExists "$.store.book[?(@.isbn)]" . }}isbn{{ end }}`, "isbn"},
+		{`{{ jsonpathExists "$.store.book[9]" . }}`, "false"},
+	}
+	for _, tt := range tests {
+		tmpl, err := template.New("t").Funcs(FuncMap()).Parse(tt.text)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.text, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, json_data); err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.text, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestFuncMap_Errors(t *testing.T) {
+	for _, text := range []string{
+		`{{ jsonpath "store" . }}`,
+		`{{ jsonpath "$.store.car" . }}`,
+		`{{ jsonpathAll "$.store.foo()" . }}`,
+		`{{ jsonpathExists "$.store[" . }}`,
+	} {
+		tmpl := template.Must(template.New("t").Funcs(FuncMap()).Parse(text))
+		var buf bytes.Buffer
+		err := tmpl.Execute(&buf, json_data)
+		if err == nil {
+			t.Errorf("%s: expected an error", text)
+		} else if !strings.Contains(err.Error(), "error calling jsonpath") {
+			t.Errorf("%s: unexpected error %v", text, err)
+		}
+	}
+}
+
+func TestFuncMap_HTMLTemplate(t *testing.T) {
+	doc := map[string]interface{}{"name": "<b>Ann</b>"}
+	tmpl := htmltemplate.Must(htmltemplate.New("t").Funcs(FuncMap()).Parse(`<p>{{ jsonpath "$.name" . }}</p>`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>&lt;b&gt;Ann&lt;/b&gt;</p>"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestFuncMap_SharedCache(t *testing.T) {
+	const path = "$.store.bicycle.price"
+	if _, err := funcLookup(path, json_data); err != nil {
+		t.Fatal(err)
+	}
+	before := funcMapCache.Stats()
+	if _, err := funcLookup(path, json_data); err != nil {
+		t.Fatal(err)
+	}
+	if after := funcMapCache.Stats(); after.Hits != before.Hits+1 || after.Misses != before.Misses {
+		t.Errorf("path was compiled again: %+v, then %+v", before, after)
+	}
+}
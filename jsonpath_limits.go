@@ -0,0 +1,92 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EvalLimits bounds the resources a query may use, for paths that come from
+// untrusted users. A zero field means no limit.
+type EvalLimits struct {
+	// MaxQueryLength is the longest path accepted by CompileWithLimits.
+	MaxQueryLength int
+	// MaxRegexLength is the longest regular expression accepted in filters,
+	// both for `=~ /pattern/` and for match() and search(). Patterns are
+	// always written in the path, so they are checked by CompileWithLimits.
+	MaxRegexLength int
+	// MaxDepth is the deepest nesting reached by recursive descent and by
+	// the copy made by Set.
+	MaxDepth int
+	// MaxNodesVisited is the number of nodes a single Lookup or Set may visit.
+	MaxNodesVisited int
+	// MaxResults is the largest number of values a step may collect in a
+	// list, like ranges, filters, recursive descent, several indices and
+	// keys applied to arrays do. A selected array counts as one value.
+	MaxResults int
+}
+
+// ErrLimitExceeded is returned when a query hits one of its EvalLimits.
+// Limit holds the name of the EvalLimits field.
+type ErrLimitExceeded struct {
+	Limit string
+	Max   int
+}
+
+func (e *ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("limit exceeded: %s is %d", e.Limit, e.Max)
+}
+
+// CompileWithLimits compiles jpath and enforces limits at compile time and in
+// every Lookup, LookupContext, Set and SetContext of the result.
+func CompileWithLimits(jpath string, limits EvalLimits) (*Compiled, error) {
+	if limits.MaxQueryLength > 0 && len(jpath) > limits.MaxQueryLength {
+		return nil, &ErrLimitExceeded{Limit: "MaxQueryLength", Max: limits.MaxQueryLength}
+	}
+	c, err := Compile(jpath)
+	if err != nil {
+		return nil, err
+	}
+	if limits.MaxRegexLength > 0 {
+		for _, s := range c.steps {
+			if s.op != "filter" {
+				continue
+			}
+			if err := checkRegexLength(s.args.(string), limits.MaxRegexLength); err != nil {
+				return nil, err
+			}
+		}
+	}
+	c.limits = limits
+	return c, nil
+}
+
+// checkRegexLength checks the patterns used by a filter expression.
+func checkRegexLength(filter string, max int) error {
+	lp, op, rp, err := parse_filter(filter)
+	if err != nil {
+		return err
+	}
+	patterns := []string{}
+	if op == "=~" {
+		patterns = append(patterns, strings.TrimSuffix(strings.TrimPrefix(rp, "/"), "/"))
+	}
+	if strings.HasSuffix(lp, ")") {
+		name, args, err := parse_func_call(lp)
+		if err != nil {
+			return err
+		}
+		if (name == "match" || name == "search") && len(args) == 2 {
+			patterns = append(patterns, strings.Trim(args[1], `"'`))
+		}
+	}
+	for _, pat := range patterns {
+		if len(pat) > max {
+			return &ErrLimitExceeded{Limit: "MaxRegexLength", Max: max}
+		}
+	}
+	return nil
+}
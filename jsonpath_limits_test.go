@@ -0,0 +1,153 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func limitName(err error) string {
+	var le *ErrLimitExceeded
+	if errors.As(err, &le) {
+		return le.Limit
+	}
+	return ""
+}
+
+func nestedDoc(depth int) interface{} {
+	var doc interface{} = "leaf"
+	for i := 0; i < depth; i++ {
+		doc = map[string]interface{}{"a": doc}
+	}
+	return doc
+}
+
+func TestCompileWithLimits_QueryLength(t *testing.T) {
+	_, err := CompileWithLimits("$.store.book[0].title", EvalLimits{MaxQueryLength: 10})
+	if limitName(err) != "MaxQueryLength" {
+		t.Errorf("expected MaxQueryLength error, got %v", err)
+	}
+	if _, err := CompileWithLimits("$.store", EvalLimits{MaxQueryLength: 10}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCompileWithLimits_RegexLength(t *testing.T) {
+	limits := EvalLimits{MaxRegexLength: 5}
+	tooLong := []string{
+		"$.book[?(@.author =~ /(?i).*REES/)]",
+		"$.book[?(match(@.author, 'N.*REES'))]",
+		`$.book[?(search(@.author, "Rees|Waugh"))]`,
+	}
+	for _, p := range tooLong {
+		if _, err := CompileWithLimits(p, limits); limitName(err) != "MaxRegexLength" {
+			t.Errorf("%s: expected MaxRegexLength error, got %v", p, err)
+		}
+	}
+	if _, err := CompileWithLimits("$.book[?(@.author =~ /Rees/)]", limits); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEvalLimits_MaxDepth(t *testing.T) {
+	doc := nestedDoc(20)
+	c, err := CompileWithLimits("$..a", EvalLimits{MaxDepth: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Lookup(doc); limitName(err) != "MaxDepth" {
+		t.Errorf("expected MaxDepth error, got %v", err)
+	}
+	if _, err := c.Lookup(nestedDoc(5)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	c, _ = CompileWithLimits("$.a", EvalLimits{MaxDepth: 10})
+	if _, err := c.Set(doc, 1); limitName(err) != "MaxDepth" {
+		t.Errorf("expected MaxDepth error from Set, got %v", err)
+	}
+}
+
+func TestEvalLimits_MaxNodesVisited(t *testing.T) {
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i}
+	}
+	doc := map[string]interface{}{"items": items}
+
+	c, _ := CompileWithLimits("$.items[?(@.id > 10)]", EvalLimits{MaxNodesVisited: 50})
+	if _, err := c.Lookup(doc); limitName(err) != "MaxNodesVisited" {
+		t.Errorf("expected MaxNodesVisited error, got %v", err)
+	}
+	c, _ = CompileWithLimits("$..id", EvalLimits{MaxNodesVisited: 50})
+	if _, err := c.Lookup(doc); limitName(err) != "MaxNodesVisited" {
+		t.Errorf("expected MaxNodesVisited error, got %v", err)
+	}
+	c, _ = CompileWithLimits("$.items.id", EvalLimits{MaxNodesVisited: 50})
+	if _, err := c.Lookup(doc); limitName(err) != "MaxNodesVisited" {
+		t.Errorf("expected MaxNodesVisited error for a key applied to an array, got %v", err)
+	}
+	c, _ = CompileWithLimits("$.items[0].id", EvalLimits{MaxNodesVisited: 50})
+	if _, err := c.Set(doc, 1); limitName(err) != "MaxNodesVisited" {
+		t.Errorf("expected MaxNodesVisited error from Set, got %v", err)
+	}
+	c, _ = CompileWithLimits("$.items[0].id", EvalLimits{MaxNodesVisited: 50})
+	if res, err := c.Lookup(doc); err != nil || res != 0 {
+		t.Errorf("expected 0, got %v, %v", res, err)
+	}
+}
+
+func TestEvalLimits_MaxResults(t *testing.T) {
+	doc := map[string]interface{}{"items": []interface{}{1, 2, 3, 4, 5}}
+	c, _ := CompileWithLimits("$.items[*]", EvalLimits{MaxResults: 3})
+	_, err := c.Lookup(doc)
+	if limitName(err) != "MaxResults" {
+		t.Errorf("expected MaxResults error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "MaxResults is 3") {
+		t.Errorf("unexpected message: %v", err)
+	}
+	c, _ = CompileWithLimits("$.items[0:3]", EvalLimits{MaxResults: 3})
+	if _, err := c.Lookup(doc); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// a selected array is one value, however long
+	for _, p := range []string{"$.items", "$.items.length()"} {
+		c, _ = CompileWithLimits(p, EvalLimits{MaxResults: 1})
+		if _, err := c.Lookup(doc); err != nil {
+			t.Errorf("%s: unexpected error: %v", p, err)
+		}
+	}
+}
+
+// Steps collecting many values stop at the limit instead of checking the
+// whole result afterwards, whatever its type.
+func TestEvalLimits_MaxResultsWhileCollecting(t *testing.T) {
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i}
+	}
+	doc := map[string]interface{}{
+		"items": items,
+		"ints":  []int{1, 2, 3, 4, 5},
+		"obj":   map[string]interface{}{"a": 1, "b": 2, "c": 3, "d": 4},
+	}
+	for _, p := range []string{
+		"$..id",
+		"$.ints[*]",
+		"$.ints[1:]",
+		"$.obj[*]",
+		"$.items[?(@.id >= 0)]",
+		"$.items.id",
+		"$.ints[0,1,2,3]",
+	} {
+		c, _ := CompileWithLimits(p, EvalLimits{MaxResults: 3})
+		if _, err := c.Lookup(doc); limitName(err) != "MaxResults" {
+			t.Errorf("%s: expected MaxResults error, got %v", p, err)
+		}
+	}
+}
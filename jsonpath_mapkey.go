@@ -0,0 +1,142 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// mapKeyName returns the object key encoding/json writes for the map key k:
+// strings as they are, then encoding.TextMarshaler keys, then integers in
+// decimal.
+func mapKeyName(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.Interface {
+		k = k.Elem()
+	}
+	if !k.IsValid() {
+		return "", fmt.Errorf("unsupported nil map key")
+	}
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		buf, err := tm.MarshalText()
+		return string(buf), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type %s", k.Type())
+}
+
+// mapIndex returns the value stored in the map m under the key named key,
+// matching keys by their mapKeyName.
+func mapIndex(m reflect.Value, key string) (reflect.Value, bool) {
+	k, ok := findMapKey(m, key)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	v := m.MapIndex(k)
+	return v, v.IsValid()
+}
+
+// findMapKey returns the key of the map m named key. Keys of types that
+// name them in one way only are returned whether m holds them or not.
+func findMapKey(m reflect.Value, key string) (reflect.Value, bool) {
+	kt := m.Type().Key()
+	switch {
+	case kt.Kind() == reflect.String:
+		return reflect.ValueOf(key).Convert(kt), true
+	case kt.Implements(textMarshalerType) || kt.Kind() == reflect.Interface:
+		iter := m.MapRange()
+		for iter.Next() {
+			if name, err := mapKeyName(iter.Key()); err == nil && name == key {
+				return iter.Key(), true
+			}
+		}
+		return reflect.Value{}, false
+	}
+	k, err := mapKeyValue(key, kt)
+	if err != nil {
+		return reflect.Value{}, false
+	}
+	// only the canonical decimal form names an integer key
+	if name, err := mapKeyName(k); err != nil || name != key {
+		return reflect.Value{}, false
+	}
+	return k, true
+}
+
+// editMapKey returns the key of the map m that edits of the member named
+// key update: the one mapIndex reads, or else a new key decoded like
+// encoding/json does, a string for interface keys.
+func editMapKey(m reflect.Value, key string) (reflect.Value, error) {
+	if k, ok := findMapKey(m, key); ok && m.MapIndex(k).IsValid() {
+		return k, nil
+	}
+	kt := m.Type().Key()
+	if kt.Kind() == reflect.Interface && reflect.TypeOf(key).AssignableTo(kt) {
+		return reflect.ValueOf(key), nil
+	}
+	k, err := mapKeyValue(key, kt)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if k.Kind() != reflect.String && !reflect.PtrTo(kt).Implements(textUnmarshalerType) {
+		// like mapIndex, only the canonical decimal form names an integer key
+		if name, err := mapKeyName(k); err != nil || name != key {
+			return reflect.Value{}, fmt.Errorf("key error: %s is not a valid %s key", key, kt)
+		}
+	}
+	return k, nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// mapKeyValue converts the path key to the key type of a map the way
+// encoding/json decodes object keys: through encoding.TextUnmarshaler,
+// as a string, or as a decimal integer.
+func mapKeyValue(key string, typ reflect.Type) (reflect.Value, error) {
+	switch {
+	case reflect.PtrTo(typ).Implements(textUnmarshalerType):
+		k := reflect.New(typ)
+		if err := k.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, fmt.Errorf("key error: %s: %v", key, err)
+		}
+		return k.Elem(), nil
+	case typ.Kind() == reflect.String:
+		return reflect.ValueOf(key).Convert(typ), nil
+	}
+
+	k := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || k.OverflowInt(n) {
+			return reflect.Value{}, fmt.Errorf("key error: %s is not a valid %s key", key, typ)
+		}
+		k.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || k.OverflowUint(n) {
+			return reflect.Value{}, fmt.Errorf("key error: %s is not a valid %s key", key, typ)
+		}
+		k.SetUint(n)
+	default:
+		return reflect.Value{}, fmt.Errorf("key error: unsupported map key type %s", typ)
+	}
+	return k, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type mapKeyID int
+
+type mapKeyPoint struct{ X, Y int }
+
+func (p mapKeyPoint) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%dx%d", p.X, p.Y)), nil
+}
+
+// mapKeyLabel is a string kind, so its MarshalText is ignored like in
+// encoding/json.
+type mapKeyLabel string
+
+func (n mapKeyLabel) MarshalText() ([]byte, error) {
+	return []byte("ignored"), nil
+}
+
+func TestLookup_MapKeyTypes(t *testing.T) {
+	tests := []struct {
+		obj      interface{}
+		path     string
+		expected interface{}
+	}{
+		{map[int]interface{}{1: "a", -2: "b"}, "$.1", "a"},
+		{map[int]interface{}{1: "a", -2: "b"}, "$.-2", "b"},
+		{map[uint8]string{200: "x"}, "$.200", "x"},
+		{map[mapKeyID]string{7: "seven"}, "$.7", "seven"},
+		{map[mapKeyPoint]int{{1, 2}: 3}, "$.1x2", 3},
+		{map[mapKeyLabel]int{"a": 1}, "$.a", 1},
+		{map[interface{}]int{"a": 1, 2: 2}, "$.2", 2},
+		{map[string]map[int]string{"ids": {3: "c"}}, "$.ids.3", "c"},
+	}
+	for _, tt := range tests {
+		res, err := JsonPathLookup(tt.obj, tt.path)
+		if err != nil {
+			t.Errorf("%s on %T: unexpected error %v", tt.path, tt.obj, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, tt.expected) {
+			t.Errorf("%s on %T: expected %v, got %v", tt.path, tt.obj, tt.expected, res)
+		}
+	}
+
+	missing := []struct {
+		obj  interface{}
+		path string
+	}{
+		{map[int]string{1: "a"}, "$.01"},
+		{map[int]string{1: "a"}, "$.one"},
+		{map[int8]string{1: "a"}, "$.300"},
+		{map[mapKeyLabel]int{"a": 1}, "$.ignored"},
+	}
+	for _, tt := range missing {
+		if res, err := JsonPathLookup(tt.obj, tt.path); err == nil {
+			t.Errorf("%s on %T: expected error, got %v", tt.path, tt.obj, res)
+		}
+	}
+}
+
+func TestLookup_MapKeyFilters(t *testing.T) {
+	doc := map[string]interface{}{
+		"rows": []interface{}{
+			map[int]int{1: 10, 2: 20},
+			map[int]int{1: 30, 2: 40},
+		},
+	}
+	res, err := JsonPathLookup(doc, "$.rows[?(@.1 > 20)].2")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if !reflect.DeepEqual(res, []interface{}{40}) {
+		t.Errorf("expected [40], got %v", res)
+	}
+}
+
+func TestLookup_MapKeyScanOrder(t *testing.T) {
+	doc := map[int]interface{}{10: "ten", 2: "two", 1: "one"}
+	for i := 0; i < 5; i++ {
+		res, err := get_scan(nil, doc)
+		if err != nil {
+			t.Fatalf("get_scan failed: %v", err)
+		}
+		if !reflect.DeepEqual(res, []interface{}{"one", "two", "ten"}) {
+			t.Fatalf("expected keys in order, got %v", res)
+		}
+	}
+}
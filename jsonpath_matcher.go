@@ -0,0 +1,321 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// selMaybeIdx is a negative index, which selects an element or not
+	// depending on the length of the array.
+	selMaybeIdx = selDescend + 1 + iota
+	// selMaybeRange is a range with negative bounds.
+	selMaybeRange
+)
+
+// Matcher finds which of many paths select a given location, such as
+// $.store.book[2].price, without a document. A path selects the locations
+// LookupNodes returns for it, so like in Lookup a key applied to an array
+// selects the key of every element, and $.store.book.price matches
+// $.store.book[2].price. What depends on the document is reported as maybe
+// matching: filters, negative indices, several indices of which a larger
+// one may be missing, and indices and ranges that pick from the lists of
+// values that ranges, filters, recursive descent and keys applied to arrays
+// make, like the [0] of $..book[0]. Paths with steps Lookup does not take,
+// such as functions and the .* wildcard, cannot be added.
+//
+// A Matcher is safe for concurrent Matches once all paths are added.
+type Matcher struct {
+	patterns []matcherPattern
+	// byKey indexes the patterns starting with a key, others holds the rest
+	byKey  map[string][]int
+	others []int
+}
+
+type matcherPattern struct {
+	id   string
+	plan []streamSel
+}
+
+// NewMatcher returns an empty Matcher.
+func NewMatcher() *Matcher {
+	return &Matcher{byKey: make(map[string][]int)}
+}
+
+// Add adds the path c under id. Paths with function or .* steps return an
+// error.
+func (m *Matcher) Add(id string, c *Compiled) error {
+	plan, err := c.matcherPlan()
+	if err != nil {
+		return err
+	}
+	i := len(m.patterns)
+	m.patterns = append(m.patterns, matcherPattern{id, plan})
+	if len(plan) > 0 && plan[0].kind == selKey {
+		m.byKey[plan[0].key] = append(m.byKey[plan[0].key], i)
+	} else {
+		m.others = append(m.others, i)
+	}
+	return nil
+}
+
+// Match returns the ids of the paths that select location, and of those
+// that may select it depending on the document, in the order they were
+// added. location is a singular path made of keys and non-negative indices
+// like the paths of LookupNodes.
+func (m *Matcher) Match(location string) (ids, maybe []string, err error) {
+	loc, err := parseLocation(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	var candidates []int
+	if key, ok := m.firstKey(loc); ok {
+		candidates = append(append([]int{}, m.byKey[key]...), m.others...)
+		sort.Ints(candidates)
+	} else {
+		// a key on a root array applies to its elements, so every path
+		// may match
+		candidates = make([]int, len(m.patterns))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+	for _, i := range candidates {
+		p := m.patterns[i]
+		switch matchLocation(p.plan, loc) {
+		case matchYes:
+			ids = append(ids, p.id)
+		case matchMaybe:
+			maybe = append(maybe, p.id)
+		}
+	}
+	return ids, maybe, nil
+}
+
+func (m *Matcher) firstKey(loc []interface{}) (string, bool) {
+	if len(loc) == 0 {
+		return "", false
+	}
+	key, ok := loc[0].(string)
+	return key, ok
+}
+
+// parseLocation returns the keys and indices of a singular path.
+func parseLocation(location string) ([]interface{}, error) {
+	c, err := Compile(location)
+	if err != nil {
+		return nil, err
+	}
+	loc := []interface{}{}
+	for _, s := range c.steps {
+		if s.op != "key" && len(s.key) > 0 {
+			loc = append(loc, s.key)
+		}
+		switch s.op {
+		case "key":
+			loc = append(loc, s.key)
+			continue
+		case "idx":
+			if indices := s.args.([]int); len(indices) == 1 && indices[0] >= 0 {
+				loc = append(loc, indices[0])
+				continue
+			}
+		}
+		return nil, fmt.Errorf("location %s is not singular", location)
+	}
+	return loc, nil
+}
+
+// matcherPlan is like streamPlan, but accepts every filter and negative
+// indices as selectors that may match.
+func (c *Compiled) matcherPlan() ([]streamSel, error) {
+	plan := []streamSel{}
+	for _, s := range c.steps {
+		if s.op != "key" && s.op != "recursive" && s.op != "member" && len(s.key) > 0 {
+			plan = append(plan, streamSel{kind: selKey, key: s.key})
+		}
+		switch s.op {
+		case "key":
+			plan = append(plan, streamSel{kind: selKey, key: s.key})
+		case "member":
+			idx, _ := s.args.([]int)
+			plan = append(plan, streamSel{kind: selMember, key: s.key, idx: idx})
+		case "idx":
+			sel := streamSel{kind: selIdx, idx: s.args.([]int)}
+			for _, x := range sel.idx {
+				if x < 0 {
+					sel.kind = selMaybeIdx
+				}
+			}
+			plan = append(plan, sel)
+		case "range":
+			argsv := s.args.([2]interface{})
+			sel := streamSel{kind: selRange, to: -1}
+			if argsv[0] != nil {
+				sel.from = argsv[0].(int)
+			}
+			if argsv[1] != nil {
+				sel.to = argsv[1].(int)
+			}
+			if sel.from < 0 || (argsv[1] != nil && sel.to < 0) {
+				sel.kind = selMaybeRange
+			}
+			plan = append(plan, sel)
+		case "filter":
+			plan = append(plan, streamSel{kind: selFilter})
+		case "recursive":
+			plan = append(plan, streamSel{kind: selDescend})
+		default:
+			return nil, fmt.Errorf("unsupported jsonpath operation for matching: %s", s.op)
+		}
+	}
+	return plan, nil
+}
+
+const (
+	matchNo = iota
+	matchMaybe
+	matchYes
+)
+
+// matcherState is a position in a plan, whether a selector that may not
+// match was passed to reach it, and whether the value there is a list made
+// by lookup rather than a value of the document.
+type matcherState struct {
+	pos   int
+	maybe bool
+	list  bool
+}
+
+// matchLocation runs plan over loc the way the streamer runs it over a
+// document, with the kinds of values told by the location: a value is an
+// array when the segment after it is an index.
+func matchLocation(plan []streamSel, loc []interface{}) int {
+	isArray := func(i int) bool {
+		if i < len(loc) {
+			_, ok := loc[i].(int)
+			return ok
+		}
+		return false
+	}
+	states := matcherClosure(plan, []matcherState{{0, false, false}}, isArray(0))
+	for i, seg := range loc {
+		states = matcherChildren(plan, states, seg)
+		if len(states) == 0 {
+			return matchNo
+		}
+		states = matcherClosure(plan, states, isArray(i+1))
+	}
+	res := matchNo
+	for _, st := range states {
+		if st.pos == len(plan) {
+			if !st.maybe {
+				return matchYes
+			}
+			res = matchMaybe
+		}
+	}
+	return res
+}
+
+// matcherClosure adds the states reached without moving to a child: the
+// descendants of recursive descent include the current value, and the
+// values of a list all share the location of the list.
+func matcherClosure(plan []streamSel, states []matcherState, isArray bool) []matcherState {
+	res := []matcherState{}
+	add := func(st matcherState) {
+		for i, y := range res {
+			if y.pos == st.pos && y.list == st.list {
+				// a certain way to reach a position wins
+				res[i].maybe = y.maybe && st.maybe
+				return
+			}
+		}
+		res = append(res, st)
+	}
+	for _, st := range states {
+		add(st)
+	}
+	for i := 0; i < len(res); i++ {
+		st := res[i]
+		if st.pos >= len(plan) {
+			continue
+		}
+		sel := plan[st.pos]
+		switch {
+		case sel.kind == selDescend:
+			// keys after recursive descent leave out arrays, whose
+			// elements are descendants of their own
+			if st.pos+1 == len(plan) || !isArray || plan[st.pos+1].kind != selKey {
+				add(matcherState{st.pos + 1, st.maybe, true})
+			}
+		case st.list && sel.kind != selKey:
+			// indices, ranges and filters pick from the list, only [*]
+			// picks everything
+			all := sel.kind == selRange && sel.from == 0 && sel.to < 0
+			add(matcherState{st.pos + 1, st.maybe || !all, true})
+			if (sel.kind == selIdx || sel.kind == selMaybeIdx) && len(sel.idx) == 1 || sel.kind == selMember {
+				// a single value of the list, which may be a list itself
+				add(matcherState{st.pos + 1, true, false})
+			}
+		}
+	}
+	return res
+}
+
+// matcherChildren moves the states to the child seg. Selectors that may
+// match advance with the maybe flag set.
+func matcherChildren(plan []streamSel, states []matcherState, seg interface{}) []matcherState {
+	next := []matcherState{}
+	for _, st := range states {
+		if st.pos >= len(plan) {
+			continue
+		}
+		sel := plan[st.pos]
+		i, isIndex := seg.(int)
+		switch {
+		case sel.kind == selKey:
+			if k, ok := seg.(string); ok && k == sel.key {
+				next = append(next, matcherState{st.pos + 1, st.maybe, st.list})
+			} else if isIndex {
+				// key on an array applies to each element, making a list
+				next = append(next, matcherState{st.pos, st.maybe, true})
+			}
+		case sel.kind == selDescend:
+			next = append(next, st)
+		case st.list:
+			// other selectors pick from the list, see matcherClosure
+		case sel.kind == selIdx:
+			if isIndex && sel.matchIdx(i) {
+				// several indices fail when one of them is missing
+				maybe := st.maybe
+				for _, x := range sel.idx {
+					maybe = maybe || x > i
+				}
+				next = append(next, matcherState{st.pos + 1, maybe, len(sel.idx) > 1})
+			}
+		case sel.kind == selRange:
+			// ranges over objects select all members
+			if !isIndex || sel.matchIdx(i) {
+				next = append(next, matcherState{st.pos + 1, st.maybe, true})
+			}
+		case sel.kind == selMaybeIdx:
+			if isIndex {
+				next = append(next, matcherState{st.pos + 1, true, len(sel.idx) > 1})
+			}
+		case sel.kind == selMaybeRange:
+			next = append(next, matcherState{st.pos + 1, st.maybe || isIndex, true})
+		case sel.kind == selFilter:
+			next = append(next, matcherState{st.pos + 1, true, true})
+		case sel.kind == selMember:
+			if sel.matchMember(seg) {
+				next = append(next, matcherState{st.pos + 1, st.maybe, false})
+			}
+		}
+	}
+	return next
+}
@@ -0,0 +1,209 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	m := NewMatcher()
+	for _, p := range []string{
+		"$.store.book[2].price",
+		"$.store.book[*].price",
+		"$.store.book[1:3].price",
+		"$.store.book[0,1].price",
+		"$.store.book.price",
+		"$..price",
+		"$.store..price",
+		"$.store[*]",
+		"$.store.book[?(@.price > 10)].price",
+		"$.store.book[-1].price",
+		"$.store.bicycle.price",
+		"$.store.book",
+		"$",
+		"$..book",
+	} {
+		if err := m.Add(p, MustCompile(p)); err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+	}
+
+	tests := []struct {
+		location string
+		ids      []string
+		maybe    []string
+	}{
+		{
+			"$.store.book[2].price",
+			[]string{"$.store.book[2].price", "$.store.book[*].price", "$.store.book[1:3].price", "$.store.book.price", "$..price", "$.store..price"},
+			[]string{"$.store.book[?(@.price > 10)].price", "$.store.book[-1].price"},
+		},
+		{
+			"$.store.book[1].price",
+			[]string{"$.store.book[*].price", "$.store.book[1:3].price", "$.store.book[0,1].price", "$.store.book.price", "$..price", "$.store..price"},
+			[]string{"$.store.book[?(@.price > 10)].price", "$.store.book[-1].price"},
+		},
+		{
+			// [0,1] selects nothing without a second book
+			"$.store.book[0].price",
+			[]string{"$.store.book[*].price", "$.store.book.price", "$..price", "$.store..price"},
+			[]string{"$.store.book[0,1].price", "$.store.book[?(@.price > 10)].price", "$.store.book[-1].price"},
+		},
+		{"$.store.bicycle.price", []string{"$..price", "$.store..price", "$.store.bicycle.price"}, nil},
+		{"$.store.bicycle", []string{"$.store[*]"}, nil},
+		{"$.store.book", []string{"$.store[*]", "$.store.book", "$..book"}, nil},
+		{"$", []string{"$"}, nil},
+		{"$.price", []string{"$..price"}, nil},
+		{"$.store.book[0].title", nil, nil},
+	}
+	for _, tt := range tests {
+		ids, maybe, err := m.Match(tt.location)
+		if err != nil {
+			t.Errorf("%s: %v", tt.location, err)
+			continue
+		}
+		if !reflect.DeepEqual(ids, tt.ids) || !reflect.DeepEqual(maybe, tt.maybe) {
+			t.Errorf("%s:\n got  %v maybe %v\n want %v maybe %v", tt.location, ids, maybe, tt.ids, tt.maybe)
+		}
+	}
+}
+
+func TestMatcher_RootArray(t *testing.T) {
+	m := NewMatcher()
+	m.Add("name", MustCompile("$.name"))
+	m.Add("first", MustCompile("$[0]"))
+	m.Add("nested", MustCompile("$..name"))
+	ids, _, err := m.Match("$[0].name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"name", "nested"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+	ids, _, _ = m.Match("$[0]")
+	if want := []string{"first"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+}
+
+// Match must agree with LookupNodes: the locations a path selects in a
+// document match or may match it, and the locations that match it are
+// selected.
+func TestMatcher_AgreesWithLookupNodes(t *testing.T) {
+	var nested interface{}
+	json.Unmarshal([]byte(`{
+		"m": [[{"k": 1}], {"k": 2}, 3],
+		"o": {"b": {"k": 3}, "a": [{"k": 4}, {"j": 5}, [{"k": 6}]]}
+	}`), &nested)
+	paths := []string{
+		"$",
+		"$.store",
+		"$.store.book",
+		"$.store.book[2].price",
+		"$.store.book[*].price",
+		"$.store.book[1:3].price",
+		"$.store.book[1:]",
+		"$.store.book[0,1].price",
+		"$.store.book[3,1]",
+		"$.store.book.price",
+		"$.store.book.author[1]",
+		"$.store.book.author[*]",
+		"$.store.book.author[1:]",
+		"$..price",
+		"$.store..price",
+		"$..book[0]",
+		"$..book[0].author",
+		"$..book[*]",
+		"$..book[?(@.isbn)]",
+		"$.store[*]",
+		"$.store[0:1]",
+		"$.store[-1:]",
+		"$.store.book[?(@.price > 10)].price",
+		"$.store.book[-1].price",
+		"$.store.book[-2:]",
+		"$.m.k",
+		"$.m[0].k",
+		"$.m[*].k",
+		"$.m[0][0]",
+		"$..k",
+		"$.o[*].k",
+		"$.o..k",
+		"$.o.a.k",
+		"$.o.a[?(@.k)]",
+		"$.o.a[-1]",
+		"$.o.a.k[1]",
+	}
+	m := NewMatcher()
+	for _, p := range paths {
+		if err := m.Add(p, MustCompile(p)); err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+	}
+	ptr, _ := FromPointer("/store/book/0/price")
+	m.Add("/store/book/0/price", ptr)
+	paths = append(paths, "/store/book/0/price")
+
+	for i, doc := range []interface{}{json_data, nested} {
+		selected := map[string]map[string]bool{}
+		for _, p := range paths {
+			c := ptr
+			if p[0] == '$' {
+				c = MustCompile(p)
+			}
+			nodes, err := c.LookupNodes(doc)
+			if err != nil {
+				t.Fatalf("doc %d, %s: %v", i, p, err)
+			}
+			selected[p] = map[string]bool{}
+			for _, n := range nodes {
+				selected[p][n.Path] = true
+			}
+		}
+		all, err := lookupDescendants(nil, traced(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range all.nodes(nil) {
+			ids, maybe, err := m.Match(n.Path)
+			if err != nil {
+				t.Fatalf("%s: %v", n.Path, err)
+			}
+			matched := map[string]bool{}
+			for _, id := range ids {
+				matched[id] = true
+				if !selected[id][n.Path] {
+					t.Errorf("doc %d: %s matches %s, but LookupNodes does not select it", i, n.Path, id)
+				}
+			}
+			for _, id := range maybe {
+				matched[id] = true
+			}
+			for _, p := range paths {
+				if selected[p][n.Path] && !matched[p] {
+					t.Errorf("doc %d: %s selects %s, but Match returned %v maybe %v", i, p, n.Path, ids, maybe)
+				}
+			}
+		}
+	}
+}
+
+func TestMatcher_Errors(t *testing.T) {
+	m := NewMatcher()
+	if err := m.Add("len", MustCompile("$.store.book.length()")); err == nil {
+		t.Errorf("expected an error for a function step")
+	}
+	// Lookup does not take .* steps
+	if err := m.Add("scan", MustCompile("$.store.*")); err == nil {
+		t.Errorf("expected an error for a scan step")
+	}
+	for _, loc := range []string{"$.store.book[*]", "$.store.book[-1]", "$..price", "$.store.*", "store"} {
+		if _, _, err := m.Match(loc); err == nil {
+			t.Errorf("%s: expected an error", loc)
+		}
+	}
+}
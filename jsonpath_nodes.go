@@ -0,0 +1,86 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+)
+
+// locNode is a value with its location as string keys and int indices.
+type locNode struct {
+	loc   []interface{}
+	value interface{}
+}
+
+func (n locNode) child(seg interface{}, value interface{}) locNode {
+	loc := make([]interface{}, len(n.loc)+1)
+	copy(loc, n.loc)
+	loc[len(n.loc)] = seg
+	return locNode{loc, value}
+}
+
+// LookupNodes is like Lookup but returns the values it selects together
+// with their normalized paths, such as $.store.book[0].price. It takes the
+// steps of Lookup, so a key applied to an array selects the key of every
+// element and $ in a filter refers to the filtered value, and the lists of
+// values that ranges, filters, recursive descent and keys applied to arrays
+// select are flattened into their nodes. Paths that Lookup does not find,
+// failing with ErrNotFound, select no nodes; other errors are returned.
+// Function steps are not supported, as their results have no location.
+func (c *Compiled) LookupNodes(obj interface{}) ([]Node, error) {
+	for _, s := range c.steps {
+		if s.op == "func" {
+			return nil, fmt.Errorf("unsupported jsonpath operation for nodes: %s", s.op)
+		}
+	}
+	t, err := c.lookupTraced(c.newEvaluator(nil), traced(obj))
+	if errors.Is(err, ErrNotFound) {
+		return []Node{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t.nodes([]Node{}), nil
+}
+
+// LookupMatches is like Lookup but returns the values the path selects as
+// a list. single tells whether the path selected one value, which is the
+// only element even if it is an array, rather than the list of values that
+// ranges, filters, recursive descent, several indices and keys applied to
+// arrays select, which is flattened into the values of its nodes like
+// LookupNodes does.
+func (c *Compiled) LookupMatches(obj interface{}) (values []interface{}, single bool, err error) {
+	t, err := c.lookupTraced(c.newEvaluator(nil), traced(obj))
+	if err != nil {
+		return nil, false, err
+	}
+	if t.from == nil || !t.from.list {
+		return []interface{}{t.value}, true, nil
+	}
+	return t.values([]interface{}{}), false, nil
+}
+
+// values appends the values of the document t is made of to res.
+func (t tracedValue) values(res []interface{}) []interface{} {
+	if !t.from.list {
+		return append(res, t.value)
+	}
+	for _, item := range t.from.items {
+		res = item.values(res)
+	}
+	return res
+}
+
+// nodes appends the values of the document t is made of to res.
+func (t tracedValue) nodes(res []Node) []Node {
+	if !t.from.list {
+		return append(res, Node{Path: normalizedPath(t.from.loc), Value: t.value})
+	}
+	for _, item := range t.from.items {
+		res = item.nodes(res)
+	}
+	return res
+}
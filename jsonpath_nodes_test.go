@@ -0,0 +1,235 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func nodePaths(nodes []Node) []string {
+	paths := []string{}
+	for _, n := range nodes {
+		paths = append(paths, n.Path)
+	}
+	return paths
+}
+
+func TestLookupNodes(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"$.expensive", []string{"$.expensive"}},
+		{"$.store.book[*].price", []string{"$.store.book[0].price", "$.store.book[1].price", "$.store.book[2].price", "$.store.book[3].price"}},
+		{"$.store.book[0,-1].title", []string{"$.store.book[0].title", "$.store.book[3].title"}},
+		{"$.store.book[1:3]", []string{"$.store.book[1]", "$.store.book[2]"}},
+		{"$.store[*]", []string{"$.store.bicycle", "$.store.book"}},
+		{"$.store.book[?(@.isbn)].isbn", []string{"$.store.book[2].isbn", "$.store.book[3].isbn"}},
+		{"$.store.book[?(@.price > 10)]", []string{"$.store.book[1]", "$.store.book[3]"}},
+		// like in Lookup, $ in a filter is the filtered value
+		{"$.store.book[?(@.price > $.expensive)]", []string{}},
+		{"$..price", []string{"$.store.bicycle.price", "$.store.book[0].price", "$.store.book[1].price", "$.store.book[2].price", "$.store.book[3].price"}},
+		{"$.store.book.author", []string{"$.store.book[0].author", "$.store.book[1].author", "$.store.book[2].author", "$.store.book[3].author"}},
+		{"$.store.book.author[1]", []string{"$.store.book[1].author"}},
+		// the recursive descent selects the book array first
+		{"$..book[0]", []string{"$.store.book"}},
+		{"$.store.missing", []string{}},
+		{"$.store.book[9]", []string{}},
+	}
+	for _, tt := range tests {
+		nodes, err := MustCompile(tt.path).LookupNodes(json_data)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.path, err)
+			continue
+		}
+		if paths := nodePaths(nodes); !reflect.DeepEqual(paths, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, paths)
+		}
+		for _, n := range nodes {
+			res, err := JsonPathLookup(json_data, n.Path)
+			if err != nil || !reflect.DeepEqual(res, n.Value) {
+				t.Errorf("%s: %s does not look up its value, got %v, %v", tt.path, n.Path, res, err)
+			}
+		}
+	}
+}
+
+func TestLookupMatches(t *testing.T) {
+	doc := map[string]interface{}{
+		"a":     []interface{}{[]interface{}{1, 2}, []interface{}{3}},
+		"items": []interface{}{map[string]interface{}{"n": "x"}, map[string]interface{}{"n": "y"}},
+	}
+	tests := []struct {
+		path     string
+		expected []interface{}
+		single   bool
+	}{
+		{"$.a[-1]", []interface{}{[]interface{}{3}}, true},
+		{"$.a", []interface{}{doc["a"]}, true},
+		{"$.a[*]", []interface{}{[]interface{}{1, 2}, []interface{}{3}}, false},
+		{"$.a[0,1]", []interface{}{[]interface{}{1, 2}, []interface{}{3}}, false},
+		{"$.items.n", []interface{}{"x", "y"}, false},
+		{"$.items[?(@.n == 'z')]", []interface{}{}, false},
+		{"$.a.length()", []interface{}{2}, true},
+	}
+	for _, tt := range tests {
+		values, single, err := MustCompile(tt.path).LookupMatches(doc)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(values, tt.expected) || single != tt.single {
+			t.Errorf("%s: expected %v, %t, got %v, %t", tt.path, tt.expected, tt.single, values, single)
+		}
+	}
+	if _, _, err := MustCompile("$.b").LookupMatches(doc); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// LookupMatches must select the values of the nodes of LookupNodes.
+func TestLookupMatches_AgreesWithLookupNodes(t *testing.T) {
+	docs := []interface{}{
+		map[string]interface{}{"a": []interface{}{[]interface{}{map[string]interface{}{"b": 1}, map[string]interface{}{"b": 2}}}},
+		[]interface{}{[]interface{}{}, map[string]interface{}{"a": 1}},
+		json_data,
+	}
+	paths := []string{"$.a.b", "$.a", "$.a[*]", "$..b", "$.store.book.author", "$.store.book[*].price", "$.store..price", "$[*].a"}
+	for i, doc := range docs {
+		for _, p := range paths {
+			c := MustCompile(p)
+			values, single, err := c.LookupMatches(doc)
+			if errors.Is(err, ErrNotFound) || single {
+				continue
+			}
+			if err != nil {
+				t.Errorf("doc %d, %s: %v", i, p, err)
+				continue
+			}
+			nodes, err := c.LookupNodes(doc)
+			if err != nil {
+				t.Fatalf("doc %d, %s: %v", i, p, err)
+			}
+			want := []interface{}{}
+			for _, n := range nodes {
+				want = append(want, n.Value)
+			}
+			if !reflect.DeepEqual(values, want) {
+				t.Errorf("doc %d, %s: LookupMatches %v, LookupNodes %v", i, p, values, want)
+			}
+		}
+	}
+}
+
+func TestLookupNodes_Typed(t *testing.T) {
+	p := structPerson{structMeta: &structMeta{ID: 7}, Name: "Ann", Home: structAddress{City: "Oslo"}}
+	nodes, err := MustCompile("$.HOME.city").LookupNodes(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].Path != "$.home.city" || nodes[0].Value != "Oslo" {
+		t.Errorf("unexpected nodes %v", nodes)
+	}
+
+	doc := map[string]interface{}{"a b": map[int]string{2: "x"}}
+	nodes, err = MustCompile(`$."a b".2`).LookupNodes(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].Path != `$."a b".2` {
+		t.Errorf("unexpected nodes %v", nodes)
+	}
+
+	if _, err := MustCompile("$.store.book.length()").LookupNodes(json_data); err == nil {
+		t.Error("expected error for function steps")
+	}
+	nodes, err = MustCompile("$..Name").LookupNodes(cycleTree())
+	if err != nil || len(nodes) != 3 {
+		t.Errorf("expected 3 names, got %v, %v", nodes, err)
+	}
+	if _, err := MustCompile("$..Name").WithCyclePolicy(CycleError).LookupNodes(cycleTree()); err != ErrCycle {
+		t.Errorf("expected ErrCycle, got %v", err)
+	}
+}
+
+// LookupNodes must select what Lookup does: the values at the paths of the
+// nodes, in the lists Lookup makes of them, are the result of Lookup.
+func TestLookupNodes_AgreesWithLookup(t *testing.T) {
+	var nested interface{}
+	json.Unmarshal([]byte(`{
+		"m": [[{"k": 1}], {"k": 2}, 3],
+		"o": {"b": {"k": 3}, "a": [{"k": 4}, {"j": 5}]}
+	}`), &nested)
+	docs := []interface{}{json_data, nested, structPerson{Name: "Ann", Home: structAddress{City: "Oslo"}}}
+	paths := []string{
+		"$",
+		"$.store.book.author",
+		"$.store.book.author[0]",
+		"$.store.book.author[1:]",
+		"$.store.book[*].author",
+		"$.store.book[1:3].price",
+		"$.store.book[-1:]",
+		"$.store.book[0,2].title",
+		"$.store[*]",
+		"$.store[*].price",
+		"$..price",
+		"$..book[0]",
+		"$..book[0].author",
+		"$..book[?(@.isbn)].title",
+		"$.store.book[?(@.price > 10)]",
+		"$.store.book[?(@.price > $.expensive)]",
+		"$.store.missing",
+		"$.store.book[9]",
+		"$.store.*",
+		"$.m.k",
+		"$.m[0].k",
+		"$.m[*].k",
+		"$..k",
+		"$.o[*].k",
+		"$.o..k",
+		"$.o.a.k",
+		"$.o.a[?(@.k)]",
+		"$.home.city",
+		"$..city",
+	}
+	for i, doc := range docs {
+		for _, p := range paths {
+			c := MustCompile(p)
+			want, werr := c.Lookup(doc)
+			traced, err := c.lookupTraced(nil, traced(doc))
+			if fmt.Sprint(err) != fmt.Sprint(werr) {
+				t.Errorf("doc %d, %s: got error %v, Lookup %v", i, p, err, werr)
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			if got := rebuildTraced(t, doc, traced); !reflect.DeepEqual(got, want) {
+				t.Errorf("doc %d, %s:\n got    %v\n Lookup %v", i, p, got, want)
+			}
+		}
+	}
+}
+
+// rebuildTraced looks up the locations of t in doc and puts the values in
+// lists like t.
+func rebuildTraced(t *testing.T, doc interface{}, tv tracedValue) interface{} {
+	if !tv.from.list {
+		v, err := MustParsePointer(jsonPointer(tv.from.loc)).Get(doc)
+		if err != nil {
+			t.Fatalf("%s: %v", normalizedPath(tv.from.loc), err)
+		}
+		return v
+	}
+	res := []interface{}{}
+	for _, item := range tv.from.items {
+		res = append(res, rebuildTraced(t, doc, item))
+	}
+	return res
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeUseNumber(t *testing.T, data string) interface{} {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	return v
+}
+
+func TestNumber_isNumber(t *testing.T) {
+	numbers := []interface{}{
+		json.Number("12"), json.Number("1.5e3"), big.NewInt(7), big.NewFloat(1.5),
+		big.NewRat(1, 3), "12345678901234567890123", "1e400",
+	}
+	for _, n := range numbers {
+		if !isNumber(n) {
+			t.Errorf("expected %v (%T) to be a number", n, n)
+		}
+	}
+	notNumbers := []interface{}{json.Number("abc"), "1/3", (*big.Int)(nil), "NaN", nil}
+	for _, n := range notNumbers {
+		if isNumber(n) {
+			t.Errorf("expected %v (%T) not to be a number", n, n)
+		}
+	}
+}
+
+func TestNumber_cmp_any_exact(t *testing.T) {
+	tests := []struct {
+		obj1, obj2 interface{}
+		op         string
+		exp        bool
+	}{
+		// float64 can not tell these apart
+		{int64(9007199254740993), int64(9007199254740992), ">", true},
+		{json.Number("9007199254740993"), "9007199254740992", ">", true},
+		{json.Number("9007199254740993"), int64(9007199254740993), "==", true},
+		{uint64(18446744073709551615), json.Number("18446744073709551614"), ">", true},
+		{big.NewInt(10), json.Number("10.0"), "==", true},
+		{big.NewFloat(2.5), 2.5, "==", true},
+		{json.Number("8.95"), 8.95, "==", true},
+		{8.95, "8.95", "==", true},
+		{json.Number("1e400"), "1e399", ">", true},
+		{float32(0.1), "0.1", "==", true},
+		{json.Number("5"), "abc", "<", true},
+	}
+	for _, tt := range tests {
+		res, err := cmp_any(tt.obj1, tt.obj2, tt.op)
+		if err != nil {
+			t.Errorf("%v %s %v: unexpected error %v", tt.obj1, tt.op, tt.obj2, err)
+			continue
+		}
+		if res != tt.exp {
+			t.Errorf("%v %s %v: expected %v, got %v", tt.obj1, tt.op, tt.obj2, tt.exp, res)
+		}
+	}
+}
+
+func TestNumber_UseNumberFilter(t *testing.T) {
+	doc := decodeUseNumber(t, `{"items": [
+		{"id": 9007199254740993, "price": 8.95},
+		{"id": 9007199254740992, "price": 12.5}
+	]}`)
+
+	res, err := JsonPathLookup(doc, "$.items[?(@.id == 9007199254740993)].price")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	expected := []interface{}{json.Number("8.95")}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	res, err = JsonPathLookup(doc, "$.items[?(@.price < 10)].id")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	expected = []interface{}{json.Number("9007199254740993")}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestNumber_length(t *testing.T) {
+	if _, err := get_length(json.Number("12345")); err == nil {
+		t.Error("expected error for length() of json.Number")
+	}
+	if _, err := get_length(big.NewInt(1)); err == nil {
+		t.Error("expected error for length() of *big.Int")
+	}
+}
+
+func TestNumber_FunctionTruthiness(t *testing.T) {
+	obj := map[string]interface{}{"tags": []interface{}{}}
+	ok, err := eval_filter(obj, obj, "count(@.tags)", "exists", "")
+	if err != nil {
+		t.Fatalf("eval_filter failed: %v", err)
+	}
+	if ok {
+		t.Error("count() of 0 should be falsy")
+	}
+}
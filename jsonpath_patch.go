@@ -0,0 +1,137 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// PatchOp is a JSON Patch (RFC 6902) operation.
+type PatchOp struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	// From is the source location of move and copy.
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON keeps a null value of add, replace and test, which need one.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	type plain PatchOp
+	switch op.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			Op    string      `json:"op"`
+			Path  string      `json:"path"`
+			Value interface{} `json:"value"`
+		}{op.Op, op.Path, op.Value})
+	}
+	return json.Marshal(plain(op))
+}
+
+// Patch is a JSON Patch (RFC 6902) document.
+type Patch []PatchOp
+
+// SetWithPatch is like Set and also returns the replace and add operations
+// that turn obj into the result, one for every value written.
+func (c *Compiled) SetWithPatch(obj interface{}, value interface{}) (interface{}, Patch, error) {
+	ev := c.editEvaluator(false, true)
+	res, err := c.set(ev, obj, value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, *ev.patch, nil
+}
+
+// JsonPathDelete removes the values at jpath, map entries and slice
+// elements, and returns a new object like JsonPathSet.
+func JsonPathDelete(obj interface{}, jpath string) (interface{}, error) {
+	c, err := compileDefault(jpath)
+	if err != nil {
+		return nil, err
+	}
+	return c.Delete(obj)
+}
+
+// Delete removes the map entries and slice elements selected by the
+// compiled path. Like Set it returns a new object unless the SetMode is
+// SetInPlace, in which case removed map entries are deleted in place.
+// Removing elements always makes a new slice, which is set back into its
+// parent.
+func (c *Compiled) Delete(obj interface{}) (interface{}, error) {
+	return c.set(c.editEvaluator(true, false), obj, nil)
+}
+
+// DeleteWithPatch is like Delete and also returns the remove operations it
+// performed. Elements of a range are removed last to first, so the
+// operations can be applied one after another.
+func (c *Compiled) DeleteWithPatch(obj interface{}) (interface{}, Patch, error) {
+	ev := c.editEvaluator(true, true)
+	res, err := c.set(ev, obj, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, *ev.patch, nil
+}
+
+func (c *Compiled) editEvaluator(deleting, patch bool) *evaluator {
+	ev := c.newEvaluator(nil)
+	if ev == nil {
+		ev = &evaluator{}
+	}
+	ev.deleting = deleting
+	if patch {
+		ev.patch = &Patch{}
+	}
+	return ev
+}
+
+func (ev *evaluator) deletes() bool {
+	return ev != nil && ev.deleting
+}
+
+// push and pop track the location of the value being updated.
+func (ev *evaluator) push(seg interface{}) {
+	if ev != nil && ev.patch != nil {
+		ev.loc = append(ev.loc, seg)
+	}
+}
+
+func (ev *evaluator) pop() {
+	if ev != nil && ev.patch != nil {
+		ev.loc = ev.loc[:len(ev.loc)-1]
+	}
+}
+
+// record adds an operation on the child seg of the current location.
+func (ev *evaluator) record(op string, seg interface{}, value interface{}) {
+	if ev == nil || ev.patch == nil {
+		return
+	}
+	loc := append(append([]interface{}{}, ev.loc...), seg)
+	*ev.patch = append(*ev.patch, PatchOp{Op: op, Path: jsonPointer(loc), Value: value})
+}
+
+// childName is the name of key in obj as it appears in JSON.
+func childName(obj interface{}, key string) string {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if f, ok := lookupField(v.Type(), key); ok {
+			return f.name
+		}
+	case reflect.Map:
+		if k, err := mapKeyValue(key, v.Type().Key()); err == nil {
+			if name, err := mapKeyName(k); err == nil {
+				return name
+			}
+		}
+	}
+	return key
+}
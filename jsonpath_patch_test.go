@@ -0,0 +1,172 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func patchDoc() map[string]interface{} {
+	return map[string]interface{}{
+		"name": "store",
+		"a/b":  map[string]interface{}{"~x": 1},
+		"book": []interface{}{
+			map[string]interface{}{"title": "a", "price": 1},
+			map[string]interface{}{"title": "b", "price": 2},
+			map[string]interface{}{"title": "c", "price": 3},
+		},
+	}
+}
+
+func TestSetWithPatch(t *testing.T) {
+	tests := []struct {
+		path     string
+		value    interface{}
+		expected Patch
+	}{
+		{"$.name", "shop", Patch{{Op: "replace", Path: "/name", Value: "shop"}}},
+		{"$.owner", "ann", Patch{{Op: "add", Path: "/owner", Value: "ann"}}},
+		{"$.book[1].price", 5, Patch{{Op: "replace", Path: "/book/1/price", Value: 5}}},
+		{"$.book[-1].isbn", "x", Patch{{Op: "add", Path: "/book/2/isbn", Value: "x"}}},
+		{"$.book[0:2].price", 0, Patch{
+			{Op: "replace", Path: "/book/0/price", Value: 0},
+			{Op: "replace", Path: "/book/1/price", Value: 0},
+		}},
+		{"$.book[1]", nil, Patch{{Op: "replace", Path: "/book/1", Value: nil}}},
+		{"$.book[0,2].price", 0, Patch{
+			{Op: "replace", Path: "/book/0/price", Value: 0},
+			{Op: "replace", Path: "/book/2/price", Value: 0},
+		}},
+		{"$.book[2,-3]", nil, Patch{
+			{Op: "replace", Path: "/book/2", Value: nil},
+			{Op: "replace", Path: "/book/0", Value: nil},
+		}},
+		{`$."a/b"."~x"`, 2, Patch{{Op: "replace", Path: "/a~1b/~0x", Value: 2}}},
+	}
+	for _, tt := range tests {
+		doc := patchDoc()
+		res, patch, err := MustCompile(tt.path).SetWithPatch(doc, tt.value)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(patch, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, patch)
+		}
+		if reflect.DeepEqual(res, doc) {
+			t.Errorf("%s: expected a changed copy", tt.path)
+		}
+	}
+}
+
+func TestSetWithPatch_Struct(t *testing.T) {
+	p := structPerson{structMeta: &structMeta{}, Home: structAddress{City: "Oslo"}}
+	_, patch, err := MustCompile("$.HOME.City").SetWithPatch(p, "Bergen")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	expected := Patch{{Op: "replace", Path: "/home/city", Value: "Bergen"}}
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("expected %v, got %v", expected, patch)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		path     string
+		check    func(map[string]interface{}) bool
+		expected Patch
+	}{
+		{"$.name", func(m map[string]interface{}) bool {
+			_, ok := m["name"]
+			return !ok
+		}, Patch{{Op: "remove", Path: "/name"}}},
+		{"$.book[1]", func(m map[string]interface{}) bool {
+			return len(m["book"].([]interface{})) == 2
+		}, Patch{{Op: "remove", Path: "/book/1"}}},
+		{"$.book[0:2]", func(m map[string]interface{}) bool {
+			book := m["book"].([]interface{})
+			return len(book) == 1 && book[0].(map[string]interface{})["title"] == "c"
+		}, Patch{{Op: "remove", Path: "/book/1"}, {Op: "remove", Path: "/book/0"}}},
+		{"$.book[0,2]", func(m map[string]interface{}) bool {
+			book := m["book"].([]interface{})
+			return len(book) == 1 && book[0].(map[string]interface{})["title"] == "b"
+		}, Patch{{Op: "remove", Path: "/book/2"}, {Op: "remove", Path: "/book/0"}}},
+		{"$.book[-1,0,0]", func(m map[string]interface{}) bool {
+			book := m["book"].([]interface{})
+			return len(book) == 1 && book[0].(map[string]interface{})["title"] == "b"
+		}, Patch{{Op: "remove", Path: "/book/2"}, {Op: "remove", Path: "/book/0"}}},
+		{"$.book[2].price", func(m map[string]interface{}) bool {
+			_, ok := m["book"].([]interface{})[2].(map[string]interface{})["price"]
+			return !ok
+		}, Patch{{Op: "remove", Path: "/book/2/price"}}},
+	}
+	for _, tt := range tests {
+		doc := patchDoc()
+		res, patch, err := MustCompile(tt.path).DeleteWithPatch(doc)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.path, err)
+			continue
+		}
+		if !tt.check(res.(map[string]interface{})) {
+			t.Errorf("%s: unexpected result %v", tt.path, res)
+		}
+		if !reflect.DeepEqual(doc, patchDoc()) {
+			t.Errorf("%s: original was modified", tt.path)
+		}
+		if !reflect.DeepEqual(patch, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, patch)
+		}
+	}
+
+	res, err := MustCompile("$.book[0,2]").Delete(patchDoc())
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if book := res.(map[string]interface{})["book"].([]interface{}); len(book) != 1 || book[0].(map[string]interface{})["title"] != "b" {
+		t.Errorf("expected only book b to remain, got %v", book)
+	}
+
+	for _, path := range []string{"$.missing", "$.book[5]", "$.book[0,5]", "$.book[0].missing"} {
+		if _, err := JsonPathDelete(patchDoc(), path); err == nil {
+			t.Errorf("%s: expected error", path)
+		}
+	}
+	if _, err := JsonPathDelete(structAddress{}, "$.city"); err == nil {
+		t.Error("expected error deleting a struct field")
+	}
+	if _, err := JsonPathDelete(map[string]interface{}{"a": [2]int{}}, "$.a[0]"); err == nil {
+		t.Error("expected error deleting from an array")
+	}
+}
+
+func TestDelete_InPlace(t *testing.T) {
+	doc := patchDoc()
+	res, err := MustCompile("$.book[0]").WithSetMode(SetInPlace).Delete(doc)
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if len(doc["book"].([]interface{})) != 2 || len(res.(map[string]interface{})["book"].([]interface{})) != 2 {
+		t.Errorf("expected book to be shortened in place, got %v", doc["book"])
+	}
+}
+
+func TestPatchOp_MarshalJSON(t *testing.T) {
+	patch := Patch{
+		{Op: "replace", Path: "/a", Value: nil},
+		{Op: "remove", Path: "/b"},
+		{Op: "add", Path: "/c", Value: false},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"op":"replace","path":"/a","value":null},{"op":"remove","path":"/b"},{"op":"add","path":"/c","value":false}]`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
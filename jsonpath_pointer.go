@@ -0,0 +1,381 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Pointer is a JSON Pointer (RFC 6901) as its unescaped reference tokens.
+// The empty Pointer refers to the whole document.
+type Pointer []string
+
+// ParsePointer parses a JSON Pointer such as "/store/book/0/price".
+func ParsePointer(s string) (Pointer, error) {
+	return pointerTokens(s)
+}
+
+// MustParsePointer is like ParsePointer but panics if s is invalid.
+func MustParsePointer(s string) Pointer {
+	p, err := ParsePointer(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// String returns the escaped form of p.
+func (p Pointer) String() string {
+	var sb strings.Builder
+	for _, token := range p {
+		sb.WriteByte('/')
+		sb.WriteString(pointerEscaper.Replace(token))
+	}
+	return sb.String()
+}
+
+// Get returns the value p refers to in obj. Tokens select map members by
+// their JSON key, struct fields like encoding/json and slice elements by
+// index.
+func (p Pointer) Get(obj interface{}) (interface{}, error) {
+	return pointerGet(obj, p)
+}
+
+// Set returns a copy of obj with the value at p set to value. Missing map
+// members are added and "-" appends to a slice; value is converted to the
+// type of its location like in Compiled.Set.
+func (p Pointer) Set(obj interface{}, value interface{}) (interface{}, error) {
+	res, err := newCopier(nil).object(obj)
+	if err != nil {
+		return nil, err
+	}
+	return pointerEdit(res, p, "set", value)
+}
+
+// Delete returns a copy of obj without the map member or slice element at p.
+func (p Pointer) Delete(obj interface{}) (interface{}, error) {
+	res, err := newCopier(nil).object(obj)
+	if err != nil {
+		return nil, err
+	}
+	return pointerEdit(res, p, "remove", nil)
+}
+
+// ToPointer returns the JSON Pointer of a singular path, one made of keys
+// and single non-negative indices only, such as $.store.book[0].price.
+func (c *Compiled) ToPointer() (Pointer, error) {
+	p := Pointer{}
+	for _, s := range c.steps {
+		switch s.op {
+		case "key", "member":
+			p = append(p, s.key)
+			continue
+		case "idx":
+			if len(s.key) > 0 {
+				p = append(p, s.key)
+			}
+			if indices := s.args.([]int); len(indices) == 1 && indices[0] >= 0 {
+				p = append(p, strconv.Itoa(indices[0]))
+				continue
+			}
+		}
+		return nil, fmt.Errorf("path %s is not singular", c.path)
+	}
+	return p, nil
+}
+
+// FromPointer compiles the JSON Pointer ptr into a path. Its steps select
+// exactly what Pointer.Get does: tokens name the members of maps and
+// structs, and the elements of slices when they are array indices, so /a/0
+// selects both {"a": [1]} and {"a": {"0": 1}}, while other tokens fail on
+// slices instead of selecting the member of every element.
+func FromPointer(ptr string) (*Compiled, error) {
+	p, err := ParsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	c := &Compiled{steps: []step{}}
+	loc := []interface{}{}
+	for _, token := range p {
+		if i, err := pointerIndex(token, math.MaxInt32); err == nil {
+			c.steps = append(c.steps, step{op: "member", key: token, args: []int{i}})
+			loc = append(loc, i)
+		} else {
+			c.steps = append(c.steps, step{op: "member", key: token})
+			loc = append(loc, token)
+		}
+	}
+	c.path = normalizedPath(loc)
+	return c, nil
+}
+
+// memberStep returns the step a member step of FromPointer takes on obj:
+// an index step on slices and arrays, provided the token is an array
+// index, and a key step on anything else.
+func memberStep(obj interface{}, s step) (step, error) {
+	v := reflect.ValueOf(obj)
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		i, err := pointerIndex(s.key, math.MaxInt32)
+		if err != nil {
+			return step{}, err
+		}
+		return step{op: "idx", args: []int{i}}, nil
+	}
+	return step{op: "key", key: s.key}, nil
+}
+
+// jsonPointer formats a location of string keys and int indices as a JSON
+// Pointer.
+func jsonPointer(loc []interface{}) string {
+	p := make(Pointer, len(loc))
+	for i, seg := range loc {
+		switch s := seg.(type) {
+		case int:
+			p[i] = strconv.Itoa(s)
+		case string:
+			p[i] = s
+		}
+	}
+	return p.String()
+}
+
+// pointerTokens splits a JSON Pointer (RFC 6901) into its unescaped
+// reference tokens.
+func pointerTokens(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with /", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, t := range tokens {
+		if !strings.Contains(t, "~") {
+			continue
+		}
+		for j := 0; j < len(t); j++ {
+			if t[j] == '~' && (j+1 == len(t) || t[j+1] != '0' && t[j+1] != '1') {
+				return nil, fmt.Errorf("invalid JSON pointer %q: bad escape", ptr)
+			}
+		}
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// pointerIndex parses an array index token, which must not have leading
+// zeros.
+func pointerIndex(token string, length int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || len(token) > 1 && token[0] == '0' || token[0] == '+' {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i >= length {
+		return 0, notFound("index out of range: len: %v, idx: %v", length, i)
+	}
+	return i, nil
+}
+
+// pointerGet returns the value at the location tokens.
+func pointerGet(obj interface{}, tokens []string) (interface{}, error) {
+	t := tracedValue{value: obj}
+	for _, token := range tokens {
+		var err error
+		if t, err = lookupMember(t, token); err != nil {
+			return nil, err
+		}
+	}
+	return t.value, nil
+}
+
+// lookupMember returns the value token refers to in t, the way a JSON
+// Pointer resolves it.
+func lookupMember(t tracedValue, token string) (tracedValue, error) {
+	v := reflect.ValueOf(t.value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		child, ok := mapIndex(v, token)
+		if !ok {
+			return tracedValue{}, notFound("key error: %s not found in object", token)
+		}
+		if t.from == nil {
+			return tracedValue{value: child.Interface()}, nil
+		}
+		return t.child(childName(t.value, token), child.Interface()), nil
+	case reflect.Slice, reflect.Array:
+		i, err := pointerIndex(token, v.Len())
+		if err != nil {
+			return tracedValue{}, err
+		}
+		return t.elem(v, i), nil
+	case reflect.Struct:
+		f, ok := lookupField(v.Type(), token)
+		if !ok {
+			return tracedValue{}, notFound("key error: %s not found in struct", token)
+		}
+		child, ok := fieldByIndex(v, f.index)
+		if !ok {
+			return tracedValue{}, ErrGetFromNullObj
+		}
+		return t.member(f.name, child.Interface()), nil
+	case reflect.Invalid:
+		return tracedValue{}, ErrGetFromNullObj
+	default:
+		return tracedValue{}, fmt.Errorf("cannot get %s from %s", token, v.Kind())
+	}
+}
+
+// pointerEdit performs the add, replace or remove op of RFC 6902, or set,
+// at the location tokens of obj and returns obj. Set adds map members,
+// appends to slices for "-" and replaces anything else. Like the set
+// functions it updates maps, slices and pointees in place and returns other
+// values updated.
+func pointerEdit(obj interface{}, tokens []string, op string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		if op == "remove" {
+			return nil, fmt.Errorf("cannot remove the document root")
+		}
+		return value, nil
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, ErrGetFromNullObj
+		}
+		newVal, err := pointerEdit(v.Elem().Interface(), tokens, op, value)
+		if err != nil {
+			return nil, err
+		}
+		converted, err := convertValue(newVal, v.Type().Elem())
+		if err != nil {
+			return nil, err
+		}
+		v.Elem().Set(converted)
+		return obj, nil
+	}
+
+	token, last := tokens[0], len(tokens) == 1
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, ErrGetFromNullObj
+		}
+		key, err := editMapKey(v, token)
+		if err != nil {
+			return nil, err
+		}
+		current := v.MapIndex(key)
+		if !current.IsValid() && (!last || op == "replace" || op == "remove") {
+			return nil, notFound("key error: %s not found in object", token)
+		}
+		if last && op == "remove" {
+			v.SetMapIndex(key, reflect.Value{})
+			return obj, nil
+		}
+		newVal := value
+		if !last {
+			if newVal, err = pointerEdit(current.Interface(), tokens[1:], op, value); err != nil {
+				return nil, err
+			}
+		}
+		converted, err := convertValue(newVal, v.Type().Elem())
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %v", token, err)
+		}
+		v.SetMapIndex(key, converted)
+		return obj, nil
+
+	case reflect.Slice, reflect.Array:
+		length := v.Len()
+		if last && (op == "add" || op == "set" && token == "-") {
+			i := length
+			if token != "-" {
+				var err error
+				if i, err = pointerIndex(token, length+1); err != nil {
+					return nil, err
+				}
+			}
+			if v.Kind() == reflect.Array {
+				return nil, fmt.Errorf("cannot add to an array")
+			}
+			converted, err := convertValue(value, v.Type().Elem())
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %v", i, err)
+			}
+			res := reflect.MakeSlice(v.Type(), 0, length+1)
+			res = reflect.AppendSlice(res, v.Slice(0, i))
+			res = reflect.Append(res, converted)
+			res = reflect.AppendSlice(res, v.Slice(i, length))
+			return res.Interface(), nil
+		}
+		i, err := pointerIndex(token, length)
+		if err != nil {
+			return nil, err
+		}
+		if last && op == "remove" {
+			return delete_elements(nil, v, i, i+1)
+		}
+		v, err = settableSlice(obj)
+		if err != nil {
+			return nil, err
+		}
+		newVal := value
+		if !last {
+			if newVal, err = pointerEdit(v.Index(i).Interface(), tokens[1:], op, value); err != nil {
+				return nil, err
+			}
+		}
+		converted, err := convertValue(newVal, v.Type().Elem())
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %v", i, err)
+		}
+		v.Index(i).Set(converted)
+		return v.Interface(), nil
+
+	case reflect.Struct:
+		f, ok := lookupField(v.Type(), token)
+		if !ok {
+			return nil, notFound("key error: %s not found in struct", token)
+		}
+		if last && op == "remove" {
+			return nil, fmt.Errorf("cannot remove struct field %s", f.name)
+		}
+		newStruct := reflect.New(v.Type()).Elem()
+		newStruct.Set(v)
+		field, err := settableFieldByIndex(newStruct, f.index, false)
+		if err != nil {
+			return nil, err
+		}
+		newVal := value
+		if !last {
+			if newVal, err = pointerEdit(field.Interface(), tokens[1:], op, value); err != nil {
+				return nil, err
+			}
+		}
+		converted, err := convertValue(newVal, field.Type())
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", token, err)
+		}
+		field.Set(converted)
+		return newStruct.Interface(), nil
+
+	case reflect.Invalid:
+		return nil, ErrGetFromNullObj
+	}
+	return nil, fmt.Errorf("cannot %s %s in %s", op, token, v.Kind())
+}
@@ -0,0 +1,319 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// example from RFC 6901 section 5
+func pointerDoc(t *testing.T) interface{} {
+	return mustJSON(t, `{
+		"foo": ["bar", "baz"],
+		"": 0,
+		"a/b": 1,
+		"c%d": 2,
+		"e^f": 3,
+		"g|h": 4,
+		"i\\j": 5,
+		"k\"l": 6,
+		" ": 7,
+		"m~n": 8
+	}`)
+}
+
+func TestPointer_Get(t *testing.T) {
+	doc := pointerDoc(t)
+	tests := []struct {
+		ptr      string
+		expected interface{}
+	}{
+		{"", doc},
+		{"/foo", []interface{}{"bar", "baz"}},
+		{"/foo/0", "bar"},
+		{"/", 0.0},
+		{"/a~1b", 1.0},
+		{"/c%d", 2.0},
+		{"/e^f", 3.0},
+		{"/g|h", 4.0},
+		{`/i\j`, 5.0},
+		{`/k"l`, 6.0},
+		{"/ ", 7.0},
+		{"/m~0n", 8.0},
+	}
+	for _, tt := range tests {
+		p, err := ParsePointer(tt.ptr)
+		if err != nil {
+			t.Errorf("%q: parse failed: %v", tt.ptr, err)
+			continue
+		}
+		if p.String() != tt.ptr {
+			t.Errorf("%q: round trip gave %q", tt.ptr, p.String())
+		}
+		res, err := p.Get(doc)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.ptr, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, tt.expected) {
+			t.Errorf("%q: expected %v, got %v", tt.ptr, tt.expected, res)
+		}
+	}
+
+	for _, ptr := range []string{"foo", "/m~2n", "/m~"} {
+		if _, err := ParsePointer(ptr); err == nil {
+			t.Errorf("%q: expected parse error", ptr)
+		}
+	}
+	for _, ptr := range []string{"/foo/2", "/foo/01", "/foo/-", "/missing", "/foo/0/x"} {
+		if res, err := MustParsePointer(ptr).Get(doc); err == nil {
+			t.Errorf("%q: expected error, got %v", ptr, res)
+		}
+	}
+}
+
+func TestPointer_GetStruct(t *testing.T) {
+	p := structPerson{structMeta: &structMeta{ID: 7}, Home: structAddress{City: "Oslo"}, Scores: [3]int{1, 2, 3}}
+	tests := map[string]interface{}{
+		"/id":        7,
+		"/home/city": "Oslo",
+		"/scores/2":  3,
+	}
+	for ptr, expected := range tests {
+		res, err := MustParsePointer(ptr).Get(p)
+		if err != nil || res != expected {
+			t.Errorf("%q: expected %v, got %v, %v", ptr, expected, res, err)
+		}
+	}
+	if res, err := MustParsePointer("/1").Get(map[int]string{1: "a"}); err != nil || res != "a" {
+		t.Errorf("expected a, got %v, %v", res, err)
+	}
+}
+
+func TestPointer_SetDelete(t *testing.T) {
+	doc := mustJSON(t, `{"foo":["bar","baz"],"obj":{"a":1}}`)
+	tests := []struct {
+		ptr      string
+		expected string
+	}{
+		{"/foo/1", `{"foo":["bar","x"],"obj":{"a":1}}`},
+		{"/foo/-", `{"foo":["bar","baz","x"],"obj":{"a":1}}`},
+		{"/obj/b", `{"foo":["bar","baz"],"obj":{"a":1,"b":"x"}}`},
+		{"", `"x"`},
+	}
+	for _, tt := range tests {
+		res, err := MustParsePointer(tt.ptr).Set(doc, "x")
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.ptr, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, mustJSON(t, tt.expected)) {
+			t.Errorf("%q: expected %s, got %v", tt.ptr, tt.expected, res)
+		}
+	}
+	if _, err := MustParsePointer("/foo/2").Set(doc, "x"); err == nil {
+		t.Error("expected error setting past the end")
+	}
+
+	res, err := MustParsePointer("/foo/0").Delete(doc)
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if !reflect.DeepEqual(res, mustJSON(t, `{"foo":["baz"],"obj":{"a":1}}`)) {
+		t.Errorf("unexpected result %v", res)
+	}
+	if !reflect.DeepEqual(doc, mustJSON(t, `{"foo":["bar","baz"],"obj":{"a":1}}`)) {
+		t.Error("original was modified")
+	}
+	if _, err := MustParsePointer("").Delete(doc); err == nil {
+		t.Error("expected error deleting the root")
+	}
+}
+
+// Edits find map members by the keys Get reads them with.
+func TestPointer_SetDeleteMapKeys(t *testing.T) {
+	doc := map[interface{}]interface{}{"a": 1, 2: "two"}
+	for ptr, expected := range map[string]map[interface{}]interface{}{
+		"/a": {"a": "x", 2: "two"},
+		"/2": {"a": 1, 2: "x"},
+		"/b": {"a": 1, 2: "two", "b": "x"},
+	} {
+		res, err := MustParsePointer(ptr).Set(doc, "x")
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", ptr, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("%s: expected %v, got %v", ptr, expected, res)
+		}
+		if got, err := MustParsePointer(ptr).Get(res); err != nil || got != "x" {
+			t.Errorf("%s: Get gave %v, %v", ptr, got, err)
+		}
+	}
+	res, err := MustParsePointer("/2").Delete(doc)
+	if err != nil || !reflect.DeepEqual(res, map[interface{}]interface{}{"a": 1}) {
+		t.Errorf("delete gave %v, %v", res, err)
+	}
+	if _, err := MustParsePointer("/c").Delete(doc); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	// only the decimal form Get reads names an integer key
+	ints := map[int]string{1: "one"}
+	if _, err := MustParsePointer("/01").Set(ints, "x"); err == nil {
+		t.Error("expected error for /01")
+	}
+	res, err = MustParsePointer("/1").Set(ints, "x")
+	if err != nil || !reflect.DeepEqual(res, map[int]string{1: "x"}) {
+		t.Errorf("set gave %v, %v", res, err)
+	}
+}
+
+func TestCompiled_ToPointer(t *testing.T) {
+	tests := map[string]string{
+		"$":                     "",
+		"$.store.book[0].price": "/store/book/0/price",
+		"$[1].name":             "/1/name",
+		`$."a/b"."m~n"`:         "/a~1b/m~0n",
+		"$.store.bicycle.color": "/store/bicycle/color",
+	}
+	for path, expected := range tests {
+		p, err := MustCompile(path).ToPointer()
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", path, err)
+			continue
+		}
+		if p.String() != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, p.String())
+		}
+	}
+	for _, path := range []string{"$.book[*]", "$.book[-1]", "$.book[0,1]", "$.book[0:2]", "$..price", "$.book[?(@.price > 1)]"} {
+		if p, err := MustCompile(path).ToPointer(); err == nil {
+			t.Errorf("%s: expected error, got %v", path, p)
+		}
+	}
+}
+
+func TestFromPointer(t *testing.T) {
+	doc := json_data
+	tests := map[string]string{
+		"/store/book/0/price": "$.store.book[0].price",
+		"/store/bicycle":      "$.store.bicycle",
+		"/a~1b/m~0n":          `$."a/b"."m~n"`,
+		"":                    "$",
+	}
+	for ptr, expected := range tests {
+		c, err := FromPointer(ptr)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", ptr, err)
+			continue
+		}
+		if c.path != expected {
+			t.Errorf("%q: expected %s, got %s", ptr, expected, c.path)
+		}
+		back, err := c.ToPointer()
+		if err != nil || back.String() != ptr {
+			t.Errorf("%q: round trip gave %q, %v", ptr, back, err)
+		}
+	}
+
+	c, _ := FromPointer("/store/book/1/author")
+	res, err := c.Lookup(doc)
+	if err != nil || res != "Evelyn Waugh" {
+		t.Errorf("expected Evelyn Waugh, got %v, %v", res, err)
+	}
+	res, err = c.Set(doc, "Anon")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if got, _ := MustParsePointer("/store/book/1/author").Get(res); got != "Anon" {
+		t.Errorf("expected Anon, got %v", got)
+	}
+
+	// numeric tokens also name members of objects
+	c, _ = FromPointer("/a/0")
+	for _, doc := range []string{`{"a": {"0": 1}}`, `{"a": [1]}`} {
+		obj := decodeJSON(t, doc)
+		if res, err := c.Lookup(obj); err != nil || res != 1.0 {
+			t.Errorf("%s: expected 1, got %v, %v", doc, res, err)
+		}
+		res, err := c.Set(obj, 2.0)
+		if err != nil {
+			t.Errorf("%s: set failed: %v", doc, err)
+		} else if got, _ := MustParsePointer("/a/0").Get(res); got != 2.0 {
+			t.Errorf("%s: expected 2, got %v", doc, got)
+		}
+		nodes, err := c.LookupNodes(obj)
+		if err != nil || len(nodes) != 1 || nodes[0].Value != 1.0 {
+			t.Errorf("%s: nodes %v, %v", doc, nodes, err)
+		}
+		var streamed []interface{}
+		err = c.Stream(strings.NewReader(doc), func(n Node) error {
+			streamed = append(streamed, n.Value)
+			return nil
+		})
+		if err != nil || !reflect.DeepEqual(streamed, []interface{}{json.Number("1")}) {
+			t.Errorf("%s: streamed %v, %v", doc, streamed, err)
+		}
+	}
+	if _, err := c.Lookup(decodeJSON(t, `{"a": {"1": 1}}`)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	m := NewMatcher()
+	if err := m.Add("p", c); err != nil {
+		t.Fatal(err)
+	}
+	for _, loc := range []string{`$.a[0]`, `$.a."0"`} {
+		if ids, _, err := m.Match(loc); err != nil || len(ids) != 1 {
+			t.Errorf("%s: matched %v, %v", loc, ids, err)
+		}
+	}
+}
+
+// FromPointer selects what Pointer.Get selects, and fails where it fails.
+func TestFromPointer_AgreesWithGet(t *testing.T) {
+	doc := decodeJSON(t, `{"a": [{"b": 1}, {"b": 2}], "m": {"01": 3, "-": 4}}`)
+	for _, ptr := range []string{
+		"/a/b", "/a/01", "/a/-", "/a/+1", "/a/2", "/a/0/b", "/a/1", "/m/01", "/m/-", "/m/x", "/a/0/b/c",
+	} {
+		want, wantErr := MustParsePointer(ptr).Get(doc)
+		c, err := FromPointer(ptr)
+		if err != nil {
+			t.Fatalf("%s: %v", ptr, err)
+		}
+		got, err := c.Lookup(doc)
+		if !reflect.DeepEqual(got, want) || (err == nil) != (wantErr == nil) {
+			t.Errorf("%s: Lookup gave %v, %v; Get gave %v, %v", ptr, got, err, want, wantErr)
+			continue
+		}
+		if err != nil && (err.Error() != wantErr.Error() || errors.Is(err, ErrNotFound) != errors.Is(wantErr, ErrNotFound)) {
+			t.Errorf("%s: Lookup failed with %v, Get with %v", ptr, err, wantErr)
+		}
+		nodes, err := c.LookupNodes(doc)
+		if wantErr == nil && (err != nil || len(nodes) != 1 || nodes[0].Path != c.path) {
+			t.Errorf("%s: nodes %v, %v", ptr, nodes, err)
+		}
+	}
+
+	// tokens that are not array indices do not create or update elements
+	for _, ptr := range []string{"/a/b", "/a/01", "/a/-"} {
+		c, _ := FromPointer(ptr)
+		if _, err := c.Set(doc, 5); err == nil {
+			t.Errorf("%s: expected Set to fail", ptr)
+		}
+	}
+	c, _ := FromPointer("/n/x")
+	res, err := c.SetCreate(map[string]interface{}{}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, map[string]interface{}{"n": map[string]interface{}{"x": 5}}) {
+		t.Errorf("SetCreate gave %v", res)
+	}
+}
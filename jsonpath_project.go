@@ -0,0 +1,98 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Project is the reverse of Bind: it builds a document from the fields of
+// the struct src, or the struct src points to, by setting every field with
+// a `jsonpath:"$.a.b[0].c"` tag at its path with SetCreate, in field order.
+// Maps and slices on the way are created as needed. A tag like
+// `jsonpath:"$.a.b,omitempty"` leaves out fields with an empty value,
+// which are false, 0, nil pointers and interfaces, and empty strings,
+// arrays, slices and maps, like encoding/json does. Fields of struct type
+// without a tag are projected the same way.
+//
+// The document is made of map[string]interface{}, []interface{} and
+// copies of the field values. Project sets every field it can and returns
+// the errors of all others as ErrFields, each an *ErrField.
+func Project(src interface{}) (interface{}, error) {
+	v := reflect.ValueOf(src)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("project source must be a struct or a pointer to one, not %T", src)
+	}
+	var doc interface{} = map[string]interface{}{}
+	var errs ErrFields
+	projectStruct(&doc, v, "", &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return doc, nil
+}
+
+func projectStruct(doc *interface{}, v reflect.Value, prefix string, errs *ErrFields) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name := prefix + sf.Name
+		field := v.Field(i)
+		tag, ok := sf.Tag.Lookup("jsonpath")
+		if !ok {
+			if sf.Type.Kind() == reflect.Struct {
+				projectStruct(doc, field, name+".", errs)
+			}
+			continue
+		}
+		pt := parsePathTag(tag)
+		if pt.path == "-" || pt.has("omitempty") && isEmptyValue(field) {
+			continue
+		}
+		if !field.CanInterface() {
+			*errs = append(*errs, &ErrField{name, pt.path, fmt.Errorf("field is unexported")})
+			continue
+		}
+		c, err := Compile(pt.path)
+		if err != nil {
+			*errs = append(*errs, &ErrField{name, pt.path, err})
+			continue
+		}
+		// the document is new, so it is safe to update in place
+		res, err := c.WithSetMode(SetInPlace).SetCreate(*doc, deepCopy(field.Interface()))
+		if err != nil {
+			*errs = append(*errs, &ErrField{name, pt.path, err})
+			continue
+		}
+		*doc = res
+	}
+}
+
+// isEmptyValue reports whether v is empty in the sense of the omitempty
+// option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
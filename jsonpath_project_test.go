@@ -0,0 +1,107 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type projectShipping struct {
+	City string `jsonpath:"$.order.shipping.address.city"`
+}
+
+type projectOrder struct {
+	ID       string            `jsonpath:"$.order.id"`
+	Qty      int               `jsonpath:"$.order.lines[0].qty"`
+	Sku      string            `jsonpath:"$.order.lines[0].sku"`
+	Extra    string            `jsonpath:"$.order.lines[1].sku"`
+	Note     string            `jsonpath:"$.order.note,omitempty"`
+	Discount *float64          `jsonpath:"$.order.discount,omitempty"`
+	Tags     []string          `jsonpath:"$.meta.tags,omitempty"`
+	Labels   map[string]string `jsonpath:"$.meta.labels"`
+	Shipping projectShipping
+	Internal string `jsonpath:"-"`
+	Plain    string
+	secret   string `jsonpath:"$.secret"`
+}
+
+func TestProject(t *testing.T) {
+	src := &projectOrder{
+		ID:       "A-1",
+		Qty:      2,
+		Sku:      "x1",
+		Extra:    "y2",
+		Tags:     []string{"new"},
+		Labels:   map[string]string{"team": "ops"},
+		Shipping: projectShipping{City: "Oslo"},
+		Internal: "hidden",
+		Plain:    "hidden",
+		secret:   "hidden",
+	}
+	doc, err := Project(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"meta":{"labels":{"team":"ops"},"tags":["new"]},"order":{"id":"A-1","lines":[{"qty":2,"sku":"x1"},{"sku":"y2"}],"shipping":{"address":{"city":"Oslo"}}}}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// the document does not share maps and slices with src
+	doc.(map[string]interface{})["meta"].(map[string]interface{})["tags"].([]string)[0] = "changed"
+	if src.Tags[0] != "new" {
+		t.Errorf("src was modified")
+	}
+
+	// and Bind reads it back
+	var back projectOrder
+	if err := Bind(doc, &back); err != nil {
+		t.Fatal(err)
+	}
+	src.Internal, src.Plain, src.secret = "", "", ""
+	src.Tags = []string{"changed"}
+	if !reflect.DeepEqual(&back, src) {
+		t.Errorf("bound back %+v, want %+v", back, *src)
+	}
+}
+
+func TestProject_OmitEmpty(t *testing.T) {
+	discount := 0.0
+	doc, err := Project(projectOrder{Discount: &discount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(doc)
+	want := `{"meta":{"labels":null},"order":{"discount":0,"id":"","lines":[{"qty":0,"sku":""},{"sku":""}],"shipping":{"address":{"city":""}}}}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestProject_Errors(t *testing.T) {
+	var conflict struct {
+		Name  string `jsonpath:"$.user"`
+		First string `jsonpath:"$.user.first"`
+		Bad   string `jsonpath:"user"`
+		Ok    int    `jsonpath:"$.ok"`
+	}
+	_, err := Project(conflict)
+	errs, ok := err.(ErrFields)
+	if !ok || len(errs) != 2 || errs[0].Field != "First" || errs[1].Field != "Bad" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	for _, src := range []interface{}{nil, 1, (*projectOrder)(nil)} {
+		if _, err := Project(src); err == nil {
+			t.Errorf("%T: expected an error", src)
+		}
+	}
+}
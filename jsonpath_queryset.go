@@ -0,0 +1,176 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"context"
+	"fmt"
+)
+
+// QuerySet evaluates many paths against a document together. Paths are
+// merged into a trie of their steps, so a prefix shared by several paths,
+// like $.store.book in $.store.book[0] and $.store.book[*].price, is
+// evaluated once per document rather than once per path.
+//
+// The limits of paths compiled with CompileWithLimits apply to the shared
+// evaluation of all paths with the same limits. A QuerySet is safe for
+// concurrent Lookups once all paths are added.
+type QuerySet struct {
+	roots []*queryRoot
+	keys  map[string]bool
+}
+
+// queryRoot is the trie of the paths sharing limits and cycle policy, which
+// are checked on the shared evaluation of all of them.
+type queryRoot struct {
+	compiled *Compiled // the settings of the paths in the trie
+	node     *queryNode
+}
+
+// queryNode is a step of the trie. Its children continue the paths that
+// go through it and keys are the paths that end at it.
+type queryNode struct {
+	step      step
+	beforeKey bool
+	children  []*queryNode
+	index     map[string]*queryNode
+	keys      []string
+}
+
+// QueryResult is the result of a path in a QuerySet, what Lookup returns
+// for it.
+type QueryResult struct {
+	Value interface{}
+	Err   error
+}
+
+// NewQuerySet returns a QuerySet of the paths, keyed by the paths
+// themselves.
+func NewQuerySet(paths ...string) (*QuerySet, error) {
+	qs := &QuerySet{}
+	for _, p := range paths {
+		if qs.keys[p] {
+			continue
+		}
+		c, err := Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		if err := qs.Add(p, c); err != nil {
+			return nil, err
+		}
+	}
+	return qs, nil
+}
+
+// Add adds c to the set under key, which must not be in the set yet.
+func (qs *QuerySet) Add(key string, c *Compiled) error {
+	if qs.keys[key] {
+		return fmt.Errorf("duplicate query key %s", key)
+	}
+	if qs.keys == nil {
+		qs.keys = make(map[string]bool)
+	}
+	qs.keys[key] = true
+
+	var root *queryRoot
+	for _, r := range qs.roots {
+		if r.compiled.limits == c.limits && r.compiled.cycles == c.cycles {
+			root = r
+			break
+		}
+	}
+	if root == nil {
+		root = &queryRoot{compiled: c, node: &queryNode{}}
+		qs.roots = append(qs.roots, root)
+	}
+
+	n := root.node
+	for i, s := range c.steps {
+		// only recursive descent depends on the next step
+		beforeKey := s.op == "recursive" && i+1 < len(c.steps) && c.steps[i+1].op == "key"
+		n = n.child(s, beforeKey)
+	}
+	n.keys = append(n.keys, key)
+	return nil
+}
+
+// Len returns the number of paths in the set.
+func (qs *QuerySet) Len() int {
+	return len(qs.keys)
+}
+
+// child returns the child for the step s, adding it when missing.
+func (n *queryNode) child(s step, beforeKey bool) *queryNode {
+	id := fmt.Sprintf("%s\x00%s\x00%v\x00%t", s.op, s.key, s.args, beforeKey)
+	if c, ok := n.index[id]; ok {
+		return c
+	}
+	if n.index == nil {
+		n.index = make(map[string]*queryNode)
+	}
+	c := &queryNode{step: s, beforeKey: beforeKey}
+	n.index[id] = c
+	n.children = append(n.children, c)
+	return c
+}
+
+// Lookup evaluates all paths against obj and returns their results by key.
+// The result of every path is the same as that of its Lookup.
+func (qs *QuerySet) Lookup(obj interface{}) map[string]QueryResult {
+	return qs.lookup(nil, obj)
+}
+
+// LookupContext is like Lookup, but once ctx is done the paths that are
+// not evaluated yet fail with ctx.Err().
+func (qs *QuerySet) LookupContext(ctx context.Context, obj interface{}) map[string]QueryResult {
+	return qs.lookup(ctx, obj)
+}
+
+func (qs *QuerySet) lookup(ctx context.Context, obj interface{}) map[string]QueryResult {
+	res := make(map[string]QueryResult, len(qs.keys))
+	for _, r := range qs.roots {
+		ev := r.compiled.newEvaluator(ctx)
+		r.node.eval(ev, obj, res)
+	}
+	return res
+}
+
+// eval stores obj as the result of the paths ending at n and evaluates
+// the children of n on it.
+func (n *queryNode) eval(ev *evaluator, obj interface{}, res map[string]QueryResult) {
+	for _, key := range n.keys {
+		res[key] = QueryResult{Value: obj}
+	}
+	for _, c := range n.children {
+		v, err := c.apply(ev, obj)
+		if err != nil {
+			c.fail(err, res)
+			continue
+		}
+		c.eval(ev, v, res)
+	}
+}
+
+func (n *queryNode) apply(ev *evaluator, obj interface{}) (interface{}, error) {
+	if err := ev.tick(); err != nil {
+		return nil, err
+	}
+	t, err := lookupStep(ev, tracedValue{value: obj}, n.step, n.beforeKey)
+	if err != nil {
+		return nil, err
+	}
+	return t.value, nil
+}
+
+// fail stores err as the result of all paths going through n.
+func (n *queryNode) fail(err error, res map[string]QueryResult) {
+	for _, key := range n.keys {
+		res[key] = QueryResult{Err: err}
+	}
+	for _, c := range n.children {
+		c.fail(err, res)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var querySetPaths = []string{
+	"$",
+	"$.expensive",
+	"$.store.bicycle.color",
+	"$.store.book",
+	"$.store.book[0]",
+	"$.store.book[0].price",
+	"$.store.book[-1].isbn",
+	"$.store.book[0,1].author",
+	"$.store.book[1:3].title",
+	"$.store.book[*].price",
+	"$.store.book[?(@.price > 10)].title",
+	"$.store.book[?(@.isbn)].isbn",
+	"$.store.book.price",
+	"$..price",
+	"$..book[0].author",
+	"$.store.book.length()",
+	"$.store.car",
+	"$.store.car.wheels",
+	"$.store.book[9]",
+	"$.store.bicycle[0]",
+}
+
+func TestQuerySet_SameAsLookup(t *testing.T) {
+	qs, err := NewQuerySet(querySetPaths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qs.Len() != len(querySetPaths) {
+		t.Errorf("Len() = %d, want %d", qs.Len(), len(querySetPaths))
+	}
+	res := qs.Lookup(json_data)
+	if len(res) != len(querySetPaths) {
+		t.Errorf("got %d results, want %d", len(res), len(querySetPaths))
+	}
+	for _, p := range querySetPaths {
+		want, wantErr := JsonPathLookup(json_data, p)
+		got, ok := res[p]
+		if !ok {
+			t.Errorf("%s: no result", p)
+			continue
+		}
+		if fmt.Sprint(got.Err) != fmt.Sprint(wantErr) || !reflect.DeepEqual(got.Value, want) {
+			t.Errorf("%s: got %v %v, want %v %v", p, got.Value, got.Err, want, wantErr)
+		}
+	}
+}
+
+func TestQuerySet_SharedPrefixes(t *testing.T) {
+	qs, err := NewQuerySet(
+		"$.store.book[0].price",
+		"$.store.book[0].title",
+		"$.store.book[*].price",
+		"$.store.bicycle.color",
+		"$.store.book[0].price", // duplicates are skipped
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// $.store is shared by all paths and $.store.book[0] by two, which
+	// leaves store, book[0], price, title, book[*], price, bicycle, color
+	var count func(n *queryNode) int
+	count = func(n *queryNode) int {
+		total := len(n.children)
+		for _, c := range n.children {
+			total += count(c)
+		}
+		return total
+	}
+	if n := count(qs.roots[0].node); n != 8 {
+		t.Errorf("trie has %d steps, want 8", n)
+	}
+	if qs.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", qs.Len())
+	}
+}
+
+func TestQuerySet_Keys(t *testing.T) {
+	qs := &QuerySet{}
+	if err := qs.Add("price", MustCompile("$.store.book[0].price")); err != nil {
+		t.Fatal(err)
+	}
+	if err := qs.Add("color", MustCompile("$.store.bicycle.color")); err != nil {
+		t.Fatal(err)
+	}
+	if err := qs.Add("price", MustCompile("$.expensive")); err == nil {
+		t.Errorf("expected an error for a duplicate key")
+	}
+	res := qs.Lookup(json_data)
+	if res["price"].Value != 8.95 || res["color"].Value != "red" || len(res) != 2 {
+		t.Errorf("got %v", res)
+	}
+
+	if _, err := NewQuerySet("$.a", "b"); err == nil {
+		t.Errorf("expected a compile error")
+	}
+}
+
+func TestQuerySet_Limits(t *testing.T) {
+	qs := &QuerySet{}
+	limited, err := CompileWithLimits("$.store.book[*].price", EvalLimits{MaxResults: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs.Add("limited", limited)
+	qs.Add("unlimited", MustCompile("$.store.book[*].price"))
+	res := qs.Lookup(json_data)
+	if _, ok := res["limited"].Err.(*ErrLimitExceeded); !ok {
+		t.Errorf("limited: expected ErrLimitExceeded, got %v", res["limited"])
+	}
+	if res["unlimited"].Err != nil {
+		t.Errorf("unlimited: %v", res["unlimited"].Err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res = qs.LookupContext(ctx, json_data)
+	for key, r := range res {
+		if r.Err != context.Canceled {
+			t.Errorf("%s: expected context.Canceled, got %v", key, r.Err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+// SetMode decides whether Set updates a copy of the object or the object
+// itself.
+type SetMode int
+
+const (
+	// SetCopy leaves the object untouched and returns an updated deep copy.
+	// This is the default.
+	SetCopy SetMode = iota
+	// SetInPlace updates maps, slices and the values behind pointers in
+	// place and returns the object. Values that are not reachable through
+	// a reference, such as a struct passed by value, are returned updated,
+	// so pass a pointer to update a struct in place.
+	SetInPlace
+)
+
+// WithSetMode returns a copy of c whose Set and SetContext use mode.
+func (c *Compiled) WithSetMode(mode SetMode) *Compiled {
+	res := *c
+	res.mode = mode
+	return &res
+}
+
+func (ev *evaluator) inPlace() bool {
+	return ev != nil && ev.mode == SetInPlace
+}
+
+// SetCreate is like Set but creates what is missing on the way to the
+// selected values: keys of maps, maps and slices in place of nil, and
+// elements of slices up to an index past their end, which are filled with
+// zero values. New containers are map[string]interface{} for keys and
+// []interface{} for indices unless the type of their location says
+// otherwise. Struct fields that do not exist are still an error.
+func (c *Compiled) SetCreate(obj interface{}, value interface{}) (interface{}, error) {
+	ev := c.editEvaluator(false, false)
+	ev.creating = true
+	return c.set(ev, obj, value)
+}
+
+func (ev *evaluator) creates() bool {
+	return ev != nil && ev.creating
+}
+
+// newContainer returns an empty container the step s can select from. The
+// member steps of FromPointer create arrays for array indices, as in
+// SetCreate of $.a[0].
+func newContainer(s step) interface{} {
+	if s.op == "member" {
+		if _, ok := s.args.([]int); ok {
+			return []interface{}{}
+		}
+		return map[string]interface{}{}
+	}
+	if s.op == "key" || len(s.key) > 0 {
+		return map[string]interface{}{}
+	}
+	return []interface{}{}
+}
@@ -319,7 +319,7 @@ func TestDeepCopy_Bool(t *testing.T) {
 
 func TestSet_recursive_UnsupportedOp(t *testing.T) {
 	steps := []step{{op: "recursive", key: "..", args: nil}}
-	_, err := set_recursive(map[string]interface{}{}, steps, 0, "value")
+	_, err := set_recursive(nil, map[string]interface{}{}, steps, 0, "value")
 	if err == nil {
 		t.Error("Expected error for unsupported operation")
 	}
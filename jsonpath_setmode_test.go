@@ -0,0 +1,189 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type setLevel string
+
+// setUpperKey decodes keys to upper case, so UnmarshalText is seen to win
+// over the string kind of the type.
+type setUpperKey string
+
+func (k *setUpperKey) UnmarshalText(text []byte) error {
+	*k = setUpperKey(strings.ToUpper(string(text)))
+	return nil
+}
+
+type setAccount struct {
+	Name  string         `json:"name"`
+	Home  *structAddress `json:"home"`
+	Tags  *[]string      `json:"tags"`
+	Quota map[int]int    `json:"quota"`
+}
+
+func TestSet_ThroughPointers(t *testing.T) {
+	tags := []string{"a", "b"}
+	acc := &setAccount{Name: "ann", Home: &structAddress{City: "Oslo"}, Tags: &tags}
+
+	res, err := JsonPathSet(acc, "$.home.city", "Bergen")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	updated := res.(*setAccount)
+	if updated == acc || updated.Home.City != "Bergen" || acc.Home.City != "Oslo" {
+		t.Errorf("expected an updated copy, got %+v and original %+v", updated.Home, acc.Home)
+	}
+
+	res, err = JsonPathSet(acc, "$.tags[1]", "c")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if got := *res.(*setAccount).Tags; !reflect.DeepEqual(got, []string{"a", "c"}) || tags[1] != "b" {
+		t.Errorf("expected [a c] in copy and b in original, got %v, %v", got, tags)
+	}
+
+	if _, err := JsonPathSet(&setAccount{}, "$.home.city", "x"); err != ErrGetFromNullObj {
+		t.Errorf("expected ErrGetFromNullObj for nil pointer, got %v", err)
+	}
+}
+
+func TestSet_InPlace(t *testing.T) {
+	tags := []string{"a", "b"}
+	acc := &setAccount{Name: "ann", Home: &structAddress{City: "Oslo"}, Tags: &tags}
+	c := MustCompile("$.home.city").WithSetMode(SetInPlace)
+	res, err := c.Set(acc, "Bergen")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if res != acc || acc.Home.City != "Bergen" {
+		t.Errorf("expected the pointee to be updated, got %+v", acc.Home)
+	}
+
+	if _, err := MustCompile("$.tags[0]").WithSetMode(SetInPlace).Set(acc, "z"); err != nil || tags[0] != "z" {
+		t.Errorf("expected slice to be updated in place, got %v, %v", tags, err)
+	}
+
+	doc := map[string]interface{}{"a": map[string]interface{}{"b": 1}}
+	if _, err := MustCompile("$.a.b").WithSetMode(SetInPlace).Set(doc, 2); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if doc["a"].(map[string]interface{})["b"] != 2 {
+		t.Errorf("expected map to be updated in place, got %v", doc)
+	}
+
+	// unexported embedded pointers can only be written through in place
+	p := &structPerson{structMeta: &structMeta{ID: 7}}
+	if _, err := MustCompile("$.id").Set(p, 8); err == nil {
+		t.Error("expected error in copy mode")
+	}
+	if _, err := MustCompile("$.id").WithSetMode(SetInPlace).Set(p, 8); err != nil || p.ID != 8 {
+		t.Errorf("expected ID 8, got %d, %v", p.ID, err)
+	}
+}
+
+func TestSet_MapKeyTypes(t *testing.T) {
+	tests := []struct {
+		obj      interface{}
+		path     string
+		value    interface{}
+		expected interface{}
+	}{
+		{map[int]string{1: "a"}, "$.2", "b", map[int]string{1: "a", 2: "b"}},
+		{map[int8]int{}, "$.-3", 1, map[int8]int{-3: 1}},
+		{map[uint]int{1: 1}, "$.1", 2.0, map[uint]int{1: 2}},
+		{map[setLevel]int{"high": 1}, "$.high", 2, map[setLevel]int{"high": 2}},
+		{map[setUpperKey]int{}, "$.abc", 1, map[setUpperKey]int{"ABC": 1}},
+		{map[interface{}]int{"a": 1, 2: 2}, "$.2", 3, map[interface{}]int{"a": 1, 2: 3}},
+		{map[int]map[string]int{1: {"x": 1}}, "$.1.x", 2, map[int]map[string]int{1: {"x": 2}}},
+		{setAccount{Quota: map[int]int{}}, "$.quota.10", 5, setAccount{Quota: map[int]int{10: 5}}},
+	}
+	for _, tt := range tests {
+		res, err := JsonPathSet(tt.obj, tt.path, tt.value)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, res)
+		}
+	}
+
+	errors := []struct {
+		obj  interface{}
+		path string
+	}{
+		{map[int]string{}, "$.one"},
+		{map[int8]string{}, "$.300"},
+		{map[uint]string{}, "$.-1"},
+		{map[float64]string{}, "$.1"},
+	}
+	for _, tt := range errors {
+		if res, err := JsonPathSet(tt.obj, tt.path, "x"); err == nil {
+			t.Errorf("%s on %T: expected error, got %v", tt.path, tt.obj, res)
+		}
+	}
+}
+
+func TestSetCreate(t *testing.T) {
+	tests := []struct {
+		path string
+		obj  interface{}
+		want string
+	}{
+		{"$.a.b.c", map[string]interface{}{}, `{"a":{"b":{"c":1}}}`},
+		{"$.a.b[1].c", map[string]interface{}{"x": true}, `{"a":{"b":[null,{"c":1}]},"x":true}`},
+		{"$.a[2]", map[string]interface{}{"a": []interface{}{0}}, `{"a":[0,null,1]}`},
+		{"$.a[0]", map[string]interface{}{"a": nil}, `{"a":[1]}`},
+		{"$[1].x", nil, `[null,{"x":1}]`},
+		{"$.a.b", nil, `{"a":{"b":1}}`},
+		{"$.a[*]", map[string]interface{}{}, `{"a":[]}`},
+	}
+	for _, tt := range tests {
+		res, err := MustCompile(tt.path).SetCreate(tt.obj, 1)
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		got, _ := json.Marshal(res)
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSetCreate_Typed(t *testing.T) {
+	type inner struct {
+		List []string
+	}
+	type outer struct {
+		Counts map[string]int
+		Inner  *inner
+	}
+	res, err := MustCompile("$.Counts.a").SetCreate(outer{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = MustCompile("$.Inner.List[1]").SetCreate(res, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.(outer)
+	if got.Counts["a"] != 3 || got.Inner == nil || !reflect.DeepEqual(got.Inner.List, []string{"", "x"}) {
+		t.Errorf("got %+v %+v", got, got.Inner)
+	}
+
+	if _, err := MustCompile("$.Missing.a").SetCreate(outer{}, 1); err == nil {
+		t.Errorf("expected an error for a missing struct field")
+	}
+	if _, err := MustCompile("$.a.b").Set(map[string]interface{}{}, 1); err == nil {
+		t.Errorf("Set should not create missing keys")
+	}
+}
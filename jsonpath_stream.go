@@ -0,0 +1,556 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotStreamable is returned when a path uses selectors that need the
+// whole document in memory, such as negative indices, functions, filters
+// that refer to the root, or indices, ranges and filters that apply to the
+// list of values an earlier selector matched.
+var ErrNotStreamable = errors.New("path is not streamable")
+
+// Node is a single value matched by a path together with its location,
+// written as a normalized path such as $.store.book[0].price.
+type Node struct {
+	Path  string
+	Value interface{}
+}
+
+// normalizedPath formats a location made of string keys and int indices.
+func normalizedPath(loc []interface{}) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, seg := range loc {
+		switch s := seg.(type) {
+		case int:
+			sb.WriteString("[")
+			sb.WriteString(strconv.Itoa(s))
+			sb.WriteString("]")
+		case string:
+			if isPlainKey(s) {
+				sb.WriteString(".")
+				sb.WriteString(s)
+			} else {
+				sb.WriteString(".")
+				sb.WriteString(quoteKey(s))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// quoteKey quotes key for dot notation, escaping the quotes and
+// backslashes in it.
+func quoteKey(key string) string {
+	var sb strings.Builder
+	sb.WriteString(`"`)
+	for _, c := range key {
+		if c == '"' || c == '\\' {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(c)
+	}
+	sb.WriteString(`"`)
+	return sb.String()
+}
+
+// isPlainKey reports whether key can be written in dot notation without quotes.
+func isPlainKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if !(c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	selKey = iota
+	selIdx
+	selRange
+	selFilter
+	// selMember is a member step of FromPointer, an index of arrays and
+	// a key of objects.
+	selMember
+	selDescend
+)
+
+// streamSel is a single selector of a streaming plan. Compiled steps such as
+// `book[0]` are split into a key selector followed by an index selector.
+type streamSel struct {
+	kind     int
+	key      string
+	idx      []int
+	from, to int // to < 0 means open ended
+	pred     *filterPredicate
+	// listed tells whether an index, range or filter follows the key,
+	// which then applies to the list of results the key makes of arrays
+	listed bool
+}
+
+// matchMember reports whether the member selector s selects the child at
+// key, a string for members of objects and an int for elements of arrays.
+func (s streamSel) matchMember(key interface{}) bool {
+	switch k := key.(type) {
+	case string:
+		return k == s.key
+	case int:
+		// only array indices select elements
+		return len(s.idx) == 1 && k == s.idx[0]
+	}
+	return false
+}
+
+func (s streamSel) matchIdx(i int) bool {
+	switch s.kind {
+	case selIdx:
+		for _, x := range s.idx {
+			if x == i {
+				return true
+			}
+		}
+	case selRange:
+		return i >= s.from && (s.to < 0 || i < s.to)
+	}
+	return false
+}
+
+// CompileStream compiles jpath and checks that it can be evaluated by
+// Stream and StreamLines.
+func CompileStream(jpath string) (*Compiled, error) {
+	c, err := Compile(jpath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.streamPlan(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// streamPlan splits the steps into selectors. Like Lookup, indices, ranges
+// and filters apply to the list of values that ranges, filters, recursive
+// descent and keys applied to arrays match, rather than to each of them,
+// which needs all of them at once.
+func (c *Compiled) streamPlan() ([]streamSel, error) {
+	plan := []streamSel{}
+	multi := false
+	for i, s := range c.steps {
+		if s.op != "key" && s.op != "recursive" && s.op != "member" && len(s.key) > 0 {
+			plan = append(plan, streamSel{kind: selKey, key: s.key})
+		}
+		switch s.op {
+		case "idx", "range", "filter":
+			if multi {
+				return nil, fmt.Errorf("%w: %s applies to a list of matches", ErrNotStreamable, s.op)
+			}
+		}
+		switch s.op {
+		case "key":
+			plan = append(plan, streamSel{kind: selKey, key: s.key})
+		case "idx":
+			for _, x := range s.args.([]int) {
+				if x < 0 {
+					return nil, fmt.Errorf("%w: negative index %d", ErrNotStreamable, x)
+				}
+			}
+			if len(s.args.([]int)) > 1 {
+				// Lookup finds none of them unless it finds all
+				return nil, fmt.Errorf("%w: several indices %v", ErrNotStreamable, s.args)
+			}
+			plan = append(plan, streamSel{kind: selIdx, idx: s.args.([]int)})
+		case "range":
+			argsv := s.args.([2]interface{})
+			sel := streamSel{kind: selRange, to: -1}
+			if argsv[0] != nil {
+				sel.from = argsv[0].(int)
+			}
+			if argsv[1] != nil {
+				sel.to = argsv[1].(int)
+			}
+			if sel.from < 0 || (argsv[1] != nil && sel.to < 0) {
+				return nil, fmt.Errorf("%w: negative range %v", ErrNotStreamable, argsv)
+			}
+			plan = append(plan, sel)
+			multi = true
+		case "filter":
+			pred, err := newFilterPredicate(s.args.(string))
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(pred.lp, "$") || strings.HasPrefix(pred.rp, "$") || strings.Contains(pred.lp, "$.") {
+				return nil, fmt.Errorf("%w: filter refers to root: %s", ErrNotStreamable, s.args)
+			}
+			plan = append(plan, streamSel{kind: selFilter, pred: pred})
+			multi = true
+		case "member":
+			idx, _ := s.args.([]int)
+			plan = append(plan, streamSel{kind: selMember, key: s.key, idx: idx})
+		case "recursive":
+			if i+1 < len(c.steps) && len(c.steps[i+1].key) == 0 {
+				return nil, fmt.Errorf("%w: recursive descent must be followed by a name", ErrNotStreamable)
+			}
+			plan = append(plan, streamSel{kind: selDescend})
+			multi = true
+		default:
+			return nil, fmt.Errorf("%w: unsupported operation %s", ErrNotStreamable, s.op)
+		}
+	}
+	listed := false
+	for i := len(plan) - 1; i >= 0; i-- {
+		switch plan[i].kind {
+		case selKey:
+			plan[i].listed = listed
+		case selIdx, selRange, selFilter:
+			listed = true
+		}
+	}
+	return plan, nil
+}
+
+// Stream evaluates the path over the JSON document read from r without
+// loading it into memory, calling fn for every match. Only matched values
+// and the elements tested by filters are decoded, with numbers as
+// json.Number. Matches are reported in document order, except within
+// decoded values, whose object members are visited in key order like
+// LookupNodes does.
+// Evaluation stops at the first error returned by fn, with the error
+// Lookup returns once an index, range or filter is applied to a value of
+// the wrong kind, and with ErrNotStreamable once a key followed by an
+// index, range or filter is applied to an array.
+func (c *Compiled) Stream(r io.Reader, fn func(Node) error) error {
+	plan, err := c.streamPlan()
+	if err != nil {
+		return err
+	}
+	s := &streamer{plan: plan, dec: newStreamDecoder(r), fn: fn}
+	return s.value([]int{0}, nil)
+}
+
+// StreamLines evaluates the path over every line of a JSON Lines input.
+// Blank lines are skipped; line numbers start at 1.
+func (c *Compiled) StreamLines(r io.Reader, fn func(line int, n Node) error) error {
+	plan, err := c.streamPlan()
+	if err != nil {
+		return err
+	}
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			s := &streamer{
+				plan: plan,
+				dec:  newStreamDecoder(bytes.NewReader(data)),
+				fn:   func(n Node) error { return fn(line, n) },
+			}
+			if serr := s.value([]int{0}, nil); serr != nil {
+				return fmt.Errorf("line %d: %w", line, serr)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// newStreamDecoder decodes numbers as json.Number, which filters compare
+// exactly, so that large integers keep their precision.
+func newStreamDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec
+}
+
+// streamer walks a token stream with a set of active plan positions.
+// A position equal to len(plan) means the current value is a match.
+type streamer struct {
+	plan []streamSel
+	dec  *json.Decoder
+	fn   func(Node) error
+}
+
+// closure adds the positions implied by recursive descent, which also
+// applies its next selector to the current value. Arrays are left out when
+// the next selector is a name, as their elements are visited on their own.
+func (s *streamer) closure(states []int, isArray bool) []int {
+	res := []int{}
+	add := func(x int) {
+		for _, y := range res {
+			if y == x {
+				return
+			}
+		}
+		res = append(res, x)
+	}
+	for _, st := range states {
+		add(st)
+		if st < len(s.plan) && s.plan[st].kind == selDescend {
+			if st+1 == len(s.plan) || !isArray {
+				add(st + 1)
+			}
+		}
+	}
+	return res
+}
+
+// children returns the positions a child inherits from its parent. pending
+// holds filter positions the child has to satisfy before advancing.
+func (s *streamer) children(states []int, key interface{}) (next, pending []int, err error) {
+	for _, st := range states {
+		if st >= len(s.plan) {
+			continue
+		}
+		sel := s.plan[st]
+		switch sel.kind {
+		case selKey:
+			if k, ok := key.(string); ok && k == sel.key {
+				next = append(next, st+1)
+			} else if !ok {
+				if sel.listed {
+					return nil, nil, fmt.Errorf("%w: key %s applied to an array before an index, range or filter", ErrNotStreamable, sel.key)
+				}
+				// key on an array applies to each element
+				next = append(next, st)
+			}
+		case selIdx:
+			if i, ok := key.(int); ok && sel.matchIdx(i) {
+				next = append(next, st+1)
+			}
+		case selRange:
+			if i, ok := key.(int); ok && sel.matchIdx(i) {
+				next = append(next, st+1)
+			} else if !ok {
+				// ranges over an object select all members, as in Lookup
+				next = append(next, st+1)
+			}
+		case selMember:
+			if sel.matchMember(key) {
+				next = append(next, st+1)
+			}
+		case selFilter:
+			pending = append(pending, st)
+		case selDescend:
+			next = append(next, st)
+		}
+	}
+	return next, pending, nil
+}
+
+// mismatch returns the error Lookup fails with when an index, range or
+// filter is applied to a value of the given kind. It is not called for
+// null, in which Lookup finds nothing.
+func (s *streamer) mismatch(states []int, kind reflect.Kind) error {
+	for _, st := range states {
+		if st >= len(s.plan) {
+			continue
+		}
+		switch s.plan[st].kind {
+		case selIdx:
+			if kind != reflect.Slice {
+				return fmt.Errorf("object is not Slice")
+			}
+		case selRange:
+			if kind != reflect.Slice && kind != reflect.Map {
+				return fmt.Errorf("object is not Slice")
+			}
+		case selFilter:
+			if kind != reflect.Slice && kind != reflect.Map {
+				return fmt.Errorf("don't support filter on this type: %v", kind)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *streamer) matches(states []int) bool {
+	for _, st := range states {
+		if st == len(s.plan) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *streamer) emit(v interface{}, loc []interface{}) error {
+	return s.fn(Node{Path: normalizedPath(loc), Value: v})
+}
+
+// value consumes the next value from the decoder. next holds the positions
+// inherited from the parent; the descent closure is applied once the kind
+// of the value is known.
+func (s *streamer) value(next []int, loc []interface{}) error {
+	if len(next) == 0 {
+		return s.skip()
+	}
+	if s.matches(s.closure(next, false)) || s.matches(s.closure(next, true)) {
+		var v interface{}
+		if err := s.dec.Decode(&v); err != nil {
+			return err
+		}
+		return s.walk(v, s.closure(next, isArrayValue(v)), loc)
+	}
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		// scalars without a match have nothing left to select
+		if tok == nil {
+			return nil
+		}
+		return s.mismatch(s.closure(next, false), reflect.TypeOf(tok).Kind())
+	}
+	states := s.closure(next, delim == '[')
+	kind := reflect.Map
+	if delim == '[' {
+		kind = reflect.Slice
+	}
+	if err := s.mismatch(states, kind); err != nil {
+		return err
+	}
+	for i := 0; s.dec.More(); i++ {
+		var key interface{} = i
+		if delim == '{' {
+			kt, err := s.dec.Token()
+			if err != nil {
+				return err
+			}
+			key = kt.(string)
+		}
+		childNext, pending, err := s.children(states, key)
+		if err != nil {
+			return err
+		}
+		childLoc := append(loc[:len(loc):len(loc)], key)
+		if len(pending) == 0 {
+			if err := s.value(childNext, childLoc); err != nil {
+				return err
+			}
+			continue
+		}
+		var v interface{}
+		if err := s.dec.Decode(&v); err != nil {
+			return err
+		}
+		if childNext, err = s.filter(v, childNext, pending); err != nil {
+			return err
+		}
+		if err := s.walk(v, s.closure(childNext, isArrayValue(v)), childLoc); err != nil {
+			return err
+		}
+	}
+	// closing delimiter
+	_, err = s.dec.Token()
+	return err
+}
+
+// skip consumes the next value without decoding it.
+func (s *streamer) skip() error {
+	depth := 0
+	for {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// filter advances the pending filter positions that v satisfies.
+func (s *streamer) filter(v interface{}, next, pending []int) ([]int, error) {
+	for _, st := range pending {
+		ok, err := s.plan[st].pred.match(v, nil)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			next = append(next, st+1)
+		}
+	}
+	return next, nil
+}
+
+// walk evaluates the remaining positions over an already decoded value.
+func (s *streamer) walk(v interface{}, states []int, loc []interface{}) error {
+	if len(states) == 0 {
+		return nil
+	}
+	if s.matches(states) {
+		if err := s.emit(v, loc); err != nil {
+			return err
+		}
+	}
+	if v != nil {
+		if err := s.mismatch(states, reflect.TypeOf(v).Kind()); err != nil {
+			return err
+		}
+	}
+	visit := func(key, child interface{}) error {
+		next, pending, err := s.children(states, key)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			next, err = s.filter(child, next, pending)
+			if err != nil {
+				return err
+			}
+		}
+		return s.walk(child, s.closure(next, isArrayValue(child)), append(loc[:len(loc):len(loc)], key))
+	}
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := visit(k, x[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range x {
+			if err := visit(i, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isArrayValue(v interface{}) bool {
+	_, ok := v.([]interface{})
+	return ok
+}
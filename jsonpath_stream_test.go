@@ -0,0 +1,355 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const streamStore = `{
+	"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "isbn": "0-553-21311-3", "price": 8.99}
+		],
+		"bicycle": {"color": "red", "price": 19.95}
+	},
+	"expensive": 10
+}`
+
+func streamCollect(t *testing.T, jpath, doc string) []Node {
+	t.Helper()
+	c, err := CompileStream(jpath)
+	if err != nil {
+		t.Fatalf("CompileStream(%s) failed: %v", jpath, err)
+	}
+	nodes := []Node{}
+	err = c.Stream(strings.NewReader(doc), func(n Node) error {
+		nodes = append(nodes, n)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream(%s) failed: %v", jpath, err)
+	}
+	return nodes
+}
+
+func streamPaths(nodes []Node) []string {
+	res := []string{}
+	for _, n := range nodes {
+		res = append(res, n.Path)
+	}
+	return res
+}
+
+func TestStream_Paths(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"$.expensive", []string{"$.expensive"}},
+		{"$.store.book[1].author", []string{"$.store.book[1].author"}},
+		{"$.store.book[1:].price", []string{"$.store.book[1].price", "$.store.book[2].price"}},
+		{"$.store.book[*].author", []string{"$.store.book[0].author", "$.store.book[1].author", "$.store.book[2].author"}},
+		{"$.store.book.author", []string{"$.store.book[0].author", "$.store.book[1].author", "$.store.book[2].author"}},
+		{"$.store.book[?(@.isbn)].author", []string{"$.store.book[2].author"}},
+		{"$.store.book[?(@.price < 10)].price", []string{"$.store.book[0].price", "$.store.book[2].price"}},
+		{"$..price", []string{"$.store.book[0].price", "$.store.book[1].price", "$.store.book[2].price", "$.store.bicycle.price"}},
+		{"$.store.missing", []string{}},
+	}
+	for _, tt := range tests {
+		got := streamPaths(streamCollect(t, tt.path, streamStore))
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestStream_Values(t *testing.T) {
+	nodes := streamCollect(t, "$.store.bicycle", streamStore)
+	if len(nodes) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(nodes))
+	}
+	expected := map[string]interface{}{"color": "red", "price": json.Number("19.95")}
+	if !reflect.DeepEqual(nodes[0].Value, expected) {
+		t.Errorf("expected %v, got %v", expected, nodes[0].Value)
+	}
+
+	nodes = streamCollect(t, "$", `[1, 2]`)
+	if len(nodes) != 1 || nodes[0].Path != "$" {
+		t.Errorf("expected root node, got %v", nodes)
+	}
+
+	nodes = streamCollect(t, `$."first name"`, `{"first name": "John"}`)
+	if len(nodes) != 1 || nodes[0].Path != `$."first name"` || nodes[0].Value != "John" {
+		t.Errorf("expected quoted key node, got %v", nodes)
+	}
+}
+
+func TestStream_MatchesLookup(t *testing.T) {
+	paths := []string{
+		"$.store.book[*].price",
+		"$.store.book[?(@.price > 10)].author",
+	}
+	for _, p := range paths {
+		var nodes []interface{}
+		for _, n := range streamCollect(t, p, streamStore) {
+			nodes = append(nodes, n.Value)
+		}
+		res, err := JsonPathLookup(decodeNumbers(t, streamStore), p)
+		if err != nil {
+			t.Fatalf("lookup %s failed: %v", p, err)
+		}
+		if !reflect.DeepEqual(res, nodes) {
+			t.Errorf("%s: lookup %v, stream %v", p, res, nodes)
+		}
+	}
+}
+
+func TestStream_LargeNumbers(t *testing.T) {
+	doc := `{"items": [{"id": 12345678901234567890, "name": "a"}, {"id": 12345678901234567891, "name": "b"}]}`
+	nodes := streamCollect(t, "$.items[?(@.id == 12345678901234567891)].name", doc)
+	if len(nodes) != 1 || nodes[0].Value != "b" {
+		t.Errorf("expected b, got %v", nodes)
+	}
+	nodes = streamCollect(t, "$.items[0].id", doc)
+	if len(nodes) != 1 || nodes[0].Value != json.Number("12345678901234567890") {
+		t.Errorf("expected exact id, got %v", nodes)
+	}
+}
+
+func TestStream_NotStreamable(t *testing.T) {
+	paths := []string{
+		"$.store.book[-1]",
+		"$.store.book[-2:]",
+		"$.store.book.length()",
+		"$.store.book[?(@.price < $.expensive)]",
+		"$..[0]",
+		"$..book[?(@.category == 'fiction')].author",
+		"$.store.book[*].tags[0]",
+		"$.store.book[0,1].tags[:1]",
+		"$.store.book[0,2].price",
+		"$.store.book[?(@.isbn)][?(@.price)]",
+	}
+	for _, p := range paths {
+		if _, err := CompileStream(p); !errors.Is(err, ErrNotStreamable) {
+			t.Errorf("%s: expected ErrNotStreamable, got %v", p, err)
+		}
+		c := MustCompile(p)
+		err := c.Stream(strings.NewReader(streamStore), func(Node) error { return nil })
+		if !errors.Is(err, ErrNotStreamable) {
+			t.Errorf("%s: Stream expected ErrNotStreamable, got %v", p, err)
+		}
+	}
+}
+
+func TestStream_KeyOnArrayBeforeIndex(t *testing.T) {
+	err := MustCompile("$.store.book.tags[0]").Stream(strings.NewReader(streamStore), func(Node) error { return nil })
+	if !errors.Is(err, ErrNotStreamable) {
+		t.Errorf("expected ErrNotStreamable, got %v", err)
+	}
+	// keys of objects stream
+	if nodes := streamCollect(t, "$.store.bicycle.color", streamStore); len(nodes) != 1 {
+		t.Errorf("expected one node, got %v", nodes)
+	}
+}
+
+func TestStream_AgreesWithLookupNodes(t *testing.T) {
+	docs := []string{`{
+		"items": [
+			{"n": 1, "tags": ["a", "b"], "sub": [{"x": 1}, {"x": 2}]},
+			{"n": 2, "tags": ["c"], "sub": [{"x": 3}]}
+		],
+		"meta": {"tags": ["d", "e"], "n": 3}
+	}`,
+		`{"a": {"a": "x", "b": {}}}`,
+		`{"a": [{"a": 1}], "n": {"z": 1, "a": 2}}`,
+		streamStore,
+	}
+	paths := []string{
+		"$.items[*].tags[0]",
+		"$.items.tags[0]",
+		"$.items[0].tags[0]",
+		"$.items[*].tags[*]",
+		"$.items.tags[1:]",
+		"$.items[*].sub[?(@.x > 1)].x",
+		"$.items.sub[?(@.x > 1)]",
+		"$.items[?(@.n > 1)].tags",
+		"$.items[*].n",
+		"$.items.n",
+		"$.meta.tags[1]",
+		"$.meta.tags[0:1]",
+		"$..tags[0]",
+		"$..sub[0].x",
+		"$..x",
+		"$..n",
+		"$.a[0:2]",
+		"$[0:1].a",
+		"$.a[*]",
+		"$.a[0]",
+		"$.a[0].a",
+		"$.n[1:]",
+		"$.a.a[0]",
+		"$.a.a[0:1]",
+		"$.a.a[?(@.b)]",
+		"$.a[?(@.a)]",
+		"$..a",
+		"$.store.book[1:].price",
+		"$.store.book[?(@.price < 10)]",
+		"$..price",
+	}
+	for _, doc := range docs {
+		obj := decodeNumbers(t, doc)
+		for _, p := range paths {
+			c := MustCompile(p)
+			got := []Node{}
+			err := c.Stream(strings.NewReader(doc), func(n Node) error {
+				got = append(got, n)
+				return nil
+			})
+			if errors.Is(err, ErrNotStreamable) {
+				continue
+			}
+			want, lerr := c.LookupNodes(obj)
+			if lerr != nil || err != nil {
+				if fmt.Sprint(err) != fmt.Sprint(lerr) {
+					t.Errorf("%s on %s: streamed error %v, LookupNodes error %v", p, doc, err, lerr)
+				}
+				continue
+			}
+			// streamed objects keep their member order
+			sortNodes(got)
+			sortNodes(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s on %s: streamed %v, LookupNodes %v", p, doc, got, want)
+			}
+		}
+	}
+}
+
+func TestStream_RangeOverObject(t *testing.T) {
+	doc := `{"a": {"a": "x", "b": {}}}`
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"$.a[0:2]", []string{"$.a.a", "$.a.b"}},
+		{"$[0:1].a", []string{"$.a.a"}},
+	}
+	for _, tt := range tests {
+		got := streamPaths(streamCollect(t, tt.path, doc))
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, got)
+		}
+	}
+	err := MustCompile("$.a[0]").Stream(strings.NewReader(doc), func(Node) error { return nil })
+	if err == nil || err.Error() != "object is not Slice" {
+		t.Errorf("expected object is not Slice, got %v", err)
+	}
+}
+
+func sortNodes(nodes []Node) {
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Path < nodes[j].Path })
+}
+
+func decodeNumbers(t *testing.T, doc string) interface{} {
+	t.Helper()
+	var obj interface{}
+	d := json.NewDecoder(strings.NewReader(doc))
+	d.UseNumber()
+	if err := d.Decode(&obj); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+func TestStream_StopOnCallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	count := 0
+	err := MustCompile("$..price").Stream(strings.NewReader(streamStore), func(Node) error {
+		count++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("expected stop error, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected callback to run once, ran %d times", count)
+	}
+}
+
+func TestStream_InvalidJSON(t *testing.T) {
+	err := MustCompile("$.a.b").Stream(strings.NewReader(`{"a": {"b": `), func(Node) error { return nil })
+	if err == nil {
+		t.Error("expected error for truncated document")
+	}
+}
+
+func TestStreamLines(t *testing.T) {
+	input := `{"level": "info", "msg": "start"}
+{"level": "error", "msg": "boom"}
+
+{"level": "error", "msg": "again"}
+`
+	type hit struct {
+		line int
+		msg  interface{}
+	}
+	var hits []hit
+	err := MustCompile("$.msg").StreamLines(strings.NewReader(input), func(line int, n Node) error {
+		hits = append(hits, hit{line, n.Value})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamLines failed: %v", err)
+	}
+	expected := []hit{{1, "start"}, {2, "boom"}, {4, "again"}}
+	if !reflect.DeepEqual(hits, expected) {
+		t.Errorf("expected %v, got %v", expected, hits)
+	}
+
+	err = MustCompile("$.msg").StreamLines(strings.NewReader("{}\n{bad\n"), func(int, Node) error { return nil })
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("expected line 2 error, got %v", err)
+	}
+}
+
+func TestNormalizedPath_Compiles(t *testing.T) {
+	doc := `{"a": {"we\"ird": [1, {"back\\slash": 2}], "first name": 3, "a.b": 4, "x/y": 5, "él": 6}}`
+	var obj interface{}
+	if err := json.Unmarshal([]byte(doc), &obj); err != nil {
+		t.Fatal(err)
+	}
+	all, err := lookupDescendants(nil, traced(obj))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range all.nodes(nil) {
+		c, err := Compile(n.Path)
+		if err != nil {
+			t.Errorf("%s: %v", n.Path, err)
+			continue
+		}
+		res, err := c.Lookup(obj)
+		if err != nil {
+			t.Errorf("%s: %v", n.Path, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, n.Value) {
+			t.Errorf("%s: selected %v, expected %v", n.Path, res, n.Value)
+		}
+		nodes := streamCollect(t, n.Path, doc)
+		if len(nodes) != 1 || nodes[0].Path != n.Path {
+			t.Errorf("%s: streamed %v", n.Path, nodes)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// structField is a field of a struct as seen by encoding/json: its JSON
+// name and the index sequence that reaches it through embedded structs.
+type structField struct {
+	name   string
+	tagged bool
+	index  []int
+	typ    reflect.Type
+}
+
+// structInfo is the cached field metadata of a struct type.
+type structInfo struct {
+	fields []structField
+	// byName maps exact names, byFoldedName lower cased names, to the
+	// position of their field in fields
+	byName       map[string]int
+	byFoldedName map[string]int
+}
+
+var structInfoCache sync.Map // map[reflect.Type]*structInfo
+
+// cachedStructInfo returns the metadata of t, computing it on first use.
+// It is safe for concurrent use.
+func cachedStructInfo(t reflect.Type) *structInfo {
+	if info, ok := structInfoCache.Load(t); ok {
+		return info.(*structInfo)
+	}
+	fields := typeFields(t)
+	info := &structInfo{
+		fields:       fields,
+		byName:       make(map[string]int, len(fields)),
+		byFoldedName: make(map[string]int, len(fields)),
+	}
+	for i, f := range fields {
+		info.byName[f.name] = i
+		folded := strings.ToLower(f.name)
+		if _, ok := info.byFoldedName[folded]; !ok {
+			info.byFoldedName[folded] = i
+		}
+	}
+	actual, _ := structInfoCache.LoadOrStore(t, info)
+	return actual.(*structInfo)
+}
+
+// structFields returns the JSON visible fields of t following the rules of
+// encoding/json: unexported and `json:"-"` fields are left out, fields of
+// embedded structs and embedded struct pointers are promoted, and a name
+// present more than once is resolved to the shallowest field, preferring
+// tagged ones, or dropped when that is ambiguous.
+func structFields(t reflect.Type) []structField {
+	return cachedStructInfo(t).fields
+}
+
+func typeFields(t reflect.Type) []structField {
+	current := []structField{}
+	next := []structField{{typ: t}}
+
+	// types and their number of occurrences at the current and next level
+	count := map[reflect.Type]int{}
+	nextCount := map[reflect.Type]int{}
+	visited := map[reflect.Type]bool{}
+
+	var fields []structField
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				exported := sf.PkgPath == ""
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					// embedded structs of unexported types may still
+					// promote exported fields
+					if !exported && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !exported {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name := tag
+				if idx := strings.Index(tag, ","); idx >= 0 {
+					name = tag[:idx]
+				}
+				if !isValidTagName(name) {
+					name = ""
+				}
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					tagged := name != ""
+					if name == "" {
+						name = sf.Name
+					}
+					fields = append(fields, structField{name: name, tagged: tagged, index: index, typ: ft})
+					if count[f.typ] > 1 {
+						// the embedding struct appears more than once at
+						// this level, record a duplicate so the name is
+						// dropped as ambiguous below
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				// explore the embedded struct at the next level
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, structField{name: ft.Name(), index: index, typ: ft})
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		x := fields
+		if x[i].name != x[j].name {
+			return x[i].name < x[j].name
+		}
+		if len(x[i].index) != len(x[j].index) {
+			return len(x[i].index) < len(x[j].index)
+		}
+		if x[i].tagged != x[j].tagged {
+			return x[i].tagged
+		}
+		return indexLess(x[i].index, x[j].index)
+	})
+
+	// keep the dominant field of every name
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		fi := fields[i]
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != fi.name {
+				break
+			}
+		}
+		if advance == 1 {
+			out = append(out, fi)
+			continue
+		}
+		dup := fields[i+1]
+		if len(fi.index) == len(dup.index) && fi.tagged == dup.tagged {
+			continue
+		}
+		out = append(out, fi)
+	}
+
+	fields = out
+	sort.Slice(fields, func(i, j int) bool {
+		return indexLess(fields[i].index, fields[j].index)
+	})
+	return fields
+}
+
+func indexLess(a, b []int) bool {
+	for k, x := range a {
+		if k >= len(b) {
+			return false
+		}
+		if x != b[k] {
+			return x < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func isValidTagName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// allowed punctuation
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// lookupField finds the field for key the way encoding/json decodes
+// objects: an exact name match first, then a case-insensitive one.
+func lookupField(t reflect.Type, key string) (structField, bool) {
+	info := cachedStructInfo(t)
+	if i, ok := info.byName[key]; ok {
+		return info.fields[i], true
+	}
+	if i, ok := info.byFoldedName[strings.ToLower(key)]; ok {
+		return info.fields[i], true
+	}
+	return structField{}, false
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false instead
+// of panicking on a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// settableFieldByIndex walks index in the addressable struct v for an update
+// and writes through embedded pointers. Pointers in unexported embedded
+// fields are not copied by Set, so they are only written through inPlace.
+func settableFieldByIndex(v reflect.Value, index []int, inPlace bool) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, ErrGetFromNullObj
+			}
+			if !v.CanSet() && !inPlace {
+				return reflect.Value{}, fmt.Errorf("cannot copy unexported embedded pointer %s, use SetInPlace", v.Type())
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
@@ -0,0 +1,245 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package jsonpath
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type structAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type structMeta struct {
+	ID      int    `json:"id"`
+	Version string `json:"version"`
+}
+
+type structAudit struct {
+	Version int    // JSON names are case sensitive, so this is not shadowed
+	By      string `json:"by"`
+	Alias   string `json:"name"` // shadowed by the shallower structPerson.Name
+}
+
+type structPerson struct {
+	*structMeta
+	structAudit
+	Name     string `json:"name"`
+	Nickname string `json:"-"`
+	Secret   string `json:"secret,omitempty"`
+	secret   string
+	Home     structAddress `json:"home"`
+	Scores   [3]int        `json:"scores"`
+}
+
+func TestStructFields_EncodingJSONRules(t *testing.T) {
+	names := []string{}
+	for _, f := range structFields(reflect.TypeOf(structPerson{})) {
+		names = append(names, f.name)
+	}
+	expected := []string{"id", "version", "Version", "by", "name", "secret", "home", "scores"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestStructFields_TagPrecedence(t *testing.T) {
+	type Tagged struct {
+		Name string `json:"Name"`
+	}
+	type Plain struct{ Name string }
+	type C struct {
+		Plain
+		Tagged
+	}
+	res, err := get_key(C{Plain{"plain"}, Tagged{"tagged"}}, "Name")
+	if err != nil || res != "tagged" {
+		t.Errorf("expected tagged field to win, got %v, %v", res, err)
+	}
+}
+
+func TestStructFields_Ambiguous(t *testing.T) {
+	type A struct{ Name string }
+	type B struct{ Name string }
+	type C struct {
+		A
+		B
+		Other string
+	}
+	for _, f := range structFields(reflect.TypeOf(C{})) {
+		if f.name == "Name" {
+			t.Errorf("ambiguous Name should be dropped")
+		}
+	}
+	if _, err := get_key(C{}, "Name"); err == nil {
+		t.Error("expected error for ambiguous field")
+	}
+}
+
+func TestStructLookup(t *testing.T) {
+	p := structPerson{
+		structMeta:  &structMeta{ID: 7, Version: "v2"},
+		structAudit: structAudit{Version: 1, By: "admin", Alias: "shadowed"},
+		Name:        "Ann",
+		Nickname:    "annie",
+		secret:      "hidden",
+		Home:        structAddress{City: "Oslo"},
+		Scores:      [3]int{1, 2, 3},
+	}
+	tests := []struct {
+		path     string
+		expected interface{}
+	}{
+		{"$.id", 7},
+		{"$.version", "v2"},
+		{"$.by", "admin"},
+		{"$.name", "Ann"},
+		{"$.Name", "Ann"},
+		{"$.home.city", "Oslo"},
+		{"$.scores[1]", 2},
+		{"$.scores[-1]", 3},
+		{"$.scores[0:2]", []int{1, 2}},
+	}
+	for _, tt := range tests {
+		res, err := JsonPathLookup(p, tt.path)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, res)
+		}
+	}
+
+	for _, path := range []string{"$.Nickname", "$.secret_field", "$.structAudit", "$.structMeta"} {
+		if res, err := JsonPathLookup(p, path); err == nil {
+			t.Errorf("%s: expected error, got %v", path, res)
+		}
+	}
+	// an exact match wins over a case-insensitive one
+	if res, _ := JsonPathLookup(p, "$.Version"); res != 1 {
+		t.Errorf("expected 1, got %v", res)
+	}
+
+	p.structMeta = nil
+	if _, err := JsonPathLookup(p, "$.id"); err != ErrGetFromNullObj {
+		t.Errorf("expected ErrGetFromNullObj through nil embedded pointer, got %v", err)
+	}
+}
+
+func TestStructLookup_Descendants(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	type order struct {
+		Items [2]item `json:"items"`
+		Note  string  `json:"-"`
+	}
+	res, err := JsonPathLookup(order{Items: [2]item{{"a"}, {"b"}}, Note: "x"}, "$..name")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if !reflect.DeepEqual(res, []interface{}{"a", "b"}) {
+		t.Errorf("expected [a b], got %v", res)
+	}
+
+	res, err = JsonPathLookup(order{Items: [2]item{{"a"}, {"b"}}}, "$.items[?(@.name == 'b')].name")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if !reflect.DeepEqual(res, []interface{}{"b"}) {
+		t.Errorf("expected [b], got %v", res)
+	}
+}
+
+func TestStructInfo_Cached(t *testing.T) {
+	typ := reflect.TypeOf(structPerson{})
+	if cachedStructInfo(typ) != cachedStructInfo(typ) {
+		t.Error("struct info should be cached per type")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			type local struct {
+				A int `json:"a"`
+			}
+			for j := 0; j < 100; j++ {
+				if res, err := get_key(local{A: j}, "a"); err != nil || res != j {
+					t.Errorf("expected %d, got %v, %v", j, res, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStructSet_FieldResolution(t *testing.T) {
+	p := structPerson{
+		structMeta: &structMeta{ID: 7, Version: "v2"},
+		Name:       "Ann",
+		secret:     "hidden",
+		Home:       structAddress{City: "Oslo"},
+	}
+
+	res, err := JsonPathSet(p, "$.home.city", "Bergen")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	updated := res.(structPerson)
+	if updated.Home.City != "Bergen" || p.Home.City != "Oslo" {
+		t.Errorf("unexpected cities %q, %q", updated.Home.City, p.Home.City)
+	}
+	if updated.secret != "hidden" {
+		t.Error("unexported fields should be kept")
+	}
+
+	// promoted through an embedded pointer that can not be copied
+	if _, err = JsonPathSet(p, "$.id", 8); err == nil {
+		t.Error("expected error for unexported embedded pointer")
+	}
+	if p.ID != 7 {
+		t.Errorf("original was modified: %d", p.ID)
+	}
+
+	// case-insensitive match, like encoding/json
+	res, err = JsonPathSet(p, "$.NAME", "Bob")
+	if err != nil || res.(structPerson).Name != "Bob" {
+		t.Errorf("expected Name Bob, got %v, %v", res, err)
+	}
+
+	if _, err := JsonPathSet(p, "$.Nickname", "x"); err == nil {
+		t.Error(`expected error for json:"-" field`)
+	}
+}
+
+type StructSetMeta struct {
+	ID int `json:"id"`
+}
+
+func TestStructSet_EmbeddedPointer(t *testing.T) {
+	type doc struct {
+		*StructSetMeta
+		Name string `json:"name"`
+	}
+	d := doc{StructSetMeta: &StructSetMeta{ID: 7}, Name: "x"}
+	res, err := JsonPathSet(d, "$.id", 8)
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if res.(doc).ID != 8 || d.ID != 7 {
+		t.Errorf("expected 8 in copy and 7 in original, got %d, %d", res.(doc).ID, d.ID)
+	}
+
+	if _, err := JsonPathSet(doc{Name: "x"}, "$.id", 8); err != ErrGetFromNullObj {
+		t.Errorf("expected ErrGetFromNullObj for nil embedded pointer, got %v", err)
+	}
+}
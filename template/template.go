@@ -0,0 +1,342 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+// Package template renders text with embedded JSONPath expressions, in the
+// syntax of kubectl's -o jsonpath templates:
+//
+//	{range .items[*]}{.metadata.name}{"\t"}{.status.phase}{"\n"}{end}
+//
+// Text outside braces is copied as is. Inside braces are
+//
+//	{.a.b}, {[0]}, {@.a}  a path relative to the current node
+//	{$.a}                 a path relative to the document
+//	{@}                   the current node
+//	{"\n"}                a Go string literal
+//	{range .a[*]}...{end} the text in between once for every match, with
+//	                      the match as the current node; ranging over a
+//	                      single array or object visits its values
+//
+// Outside of range the current node is the document. Every path is
+// evaluated with Compiled.LookupMatches. Strings are written without
+// quotes, objects and arrays as JSON, and the matches of paths that select
+// several values, like .items[*].name or .items.name on an array of items,
+// separated by spaces.
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/oliveagle/jsonpath"
+)
+
+// Template is a parsed template.
+type Template struct {
+	name             string
+	nodes            []node
+	allowMissingKeys bool
+}
+
+type node interface{}
+
+// textNode is text copied to the output, from outside braces or a literal.
+type textNode string
+
+// exprNode writes the result of a path.
+type exprNode struct {
+	text string
+	path *jsonpath.Compiled
+	// root is set for paths starting with $, which are evaluated against
+	// the document instead of the current node.
+	root bool
+}
+
+// rangeNode executes body for every match of expr.
+type rangeNode struct {
+	expr exprNode
+	body []node
+}
+
+// New returns an empty template with the given name, which is used in
+// error messages.
+func New(name string) *Template {
+	return &Template{name: name}
+}
+
+// Must panics if err is not nil and otherwise returns t, for use in
+// variable initializations like template.Must(template.New("x").Parse(s)).
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// AllowMissingKeys makes paths that select keys or indices that do not
+// exist write nothing instead of failing the execution.
+func (t *Template) AllowMissingKeys(allow bool) *Template {
+	t.allowMissingKeys = allow
+	return t
+}
+
+// Parse parses text as the body of t.
+func (t *Template) Parse(text string) (*Template, error) {
+	p := &parser{name: t.name, text: text}
+	nodes, err := p.parse(false)
+	if err != nil {
+		return nil, err
+	}
+	t.nodes = nodes
+	return t, nil
+}
+
+// Execute renders the template for data to w.
+func (t *Template) Execute(w io.Writer, data interface{}) error {
+	e := &executor{t: t, w: w, root: data}
+	return e.walk(t.nodes, data)
+}
+
+type parser struct {
+	name string
+	text string
+	pos  int
+}
+
+// parse parses nodes up to the end of the text or, inside range, up to
+// the matching {end}.
+func (p *parser) parse(inRange bool) ([]node, error) {
+	nodes := []node{}
+	for p.pos < len(p.text) {
+		open := strings.IndexByte(p.text[p.pos:], '{')
+		if open < 0 {
+			nodes = append(nodes, textNode(p.text[p.pos:]))
+			p.pos = len(p.text)
+			break
+		}
+		if open > 0 {
+			nodes = append(nodes, textNode(p.text[p.pos:p.pos+open]))
+		}
+		start := p.pos + open
+		end, err := p.actionEnd(start)
+		if err != nil {
+			return nil, err
+		}
+		action := strings.TrimSpace(p.text[start+1 : end])
+		p.pos = end + 1
+
+		switch {
+		case action == "end":
+			if !inRange {
+				return nil, p.errorf(start, "unexpected {end}")
+			}
+			return nodes, nil
+		case action == "range" || strings.HasPrefix(action, "range "):
+			expr, err := p.expr(start, strings.TrimSpace(strings.TrimPrefix(action, "range")))
+			if err != nil {
+				return nil, err
+			}
+			body, err := p.parse(true)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, rangeNode{expr: expr, body: body})
+		case strings.HasPrefix(action, `"`):
+			s, err := strconv.Unquote(action)
+			if err != nil {
+				return nil, p.errorf(start, "invalid string literal %s", action)
+			}
+			nodes = append(nodes, textNode(s))
+		default:
+			expr, err := p.expr(start, action)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, expr)
+		}
+	}
+	if inRange {
+		return nil, p.errorf(len(p.text), "missing {end}")
+	}
+	return nodes, nil
+}
+
+// actionEnd returns the offset of the brace closing the action opened at
+// start, skipping braces in quotes, brackets and parentheses.
+func (p *parser) actionEnd(start int) (int, error) {
+	depth := 0
+	var quote byte
+	for i := start + 1; i < len(p.text); i++ {
+		c := p.text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == '}' && depth <= 0:
+			return i, nil
+		}
+	}
+	return 0, p.errorf(start, "unclosed action")
+}
+
+// expr compiles the path of an action, turning paths relative to the
+// current node into paths starting with $.
+func (p *parser) expr(start int, text string) (exprNode, error) {
+	if text == "" {
+		return exprNode{}, p.errorf(start, "missing path")
+	}
+	path, root := text, false
+	switch {
+	case strings.HasPrefix(text, "$"):
+		root = true
+	case strings.HasPrefix(text, "@"):
+		path = "$" + text[1:]
+	case strings.HasPrefix(text, ".") || strings.HasPrefix(text, "["):
+		path = "$" + text
+	default:
+		path = "$." + text
+	}
+	c, err := jsonpath.Compile(path)
+	if err != nil {
+		return exprNode{}, p.errorf(start, "%s: %v", text, err)
+	}
+	return exprNode{text: text, path: c, root: root}, nil
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("template %s:%d: %s", p.name, pos, fmt.Sprintf(format, args...))
+}
+
+type executor struct {
+	t    *Template
+	w    io.Writer
+	root interface{}
+}
+
+func (e *executor) walk(nodes []node, current interface{}) error {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case textNode:
+			if _, err := io.WriteString(e.w, string(n)); err != nil {
+				return err
+			}
+		case exprNode:
+			values, _, err := e.eval(n, current)
+			if err != nil {
+				return err
+			}
+			for i, v := range values {
+				if i > 0 {
+					if _, err := io.WriteString(e.w, " "); err != nil {
+						return err
+					}
+				}
+				if err := e.print(n, v); err != nil {
+					return err
+				}
+			}
+		case rangeNode:
+			values, single, err := e.eval(n.expr, current)
+			if err != nil {
+				return err
+			}
+			if single {
+				// ranging over an array or object iterates its values
+				values = elements(values[0])
+			}
+			for _, v := range values {
+				if err := e.walk(n.body, v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// eval returns the values selected by the path of n and whether it selected
+// a single value.
+func (e *executor) eval(n exprNode, current interface{}) ([]interface{}, bool, error) {
+	obj := current
+	if n.root {
+		obj = e.root
+	}
+	values, single, err := n.path.LookupMatches(obj)
+	if err != nil {
+		if e.t.allowMissingKeys && errors.Is(err, jsonpath.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("template %s: executing {%s}: %v", e.t.name, n.text, err)
+	}
+	return values, single, nil
+}
+
+func (e *executor) print(n exprNode, v interface{}) error {
+	var s string
+	switch v := v.(type) {
+	case nil:
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		switch rv.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			buf, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("template %s: executing {%s}: %v", e.t.name, n.text, err)
+			}
+			s = string(buf)
+		case reflect.Ptr:
+		default:
+			s = fmt.Sprint(rv.Interface())
+		}
+	}
+	_, err := io.WriteString(e.w, s)
+	return err
+}
+
+// allValues selects the values of objects in the key order of the
+// jsonpath package.
+var allValues = jsonpath.MustCompile("$[*]")
+
+// elements returns the elements of an array, the values of an object in
+// key order, or v itself for anything else.
+func elements(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		res := make([]interface{}, rv.Len())
+		for i := range res {
+			res[i] = rv.Index(i).Interface()
+		}
+		return res
+	case reflect.Map:
+		// in the key order of [*]
+		if res, err := allValues.Lookup(rv.Interface()); err == nil {
+			return res.([]interface{})
+		}
+	}
+	return []interface{}{v}
+}
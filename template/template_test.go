@@ -0,0 +1,200 @@
+// Copyright 2026 oliveagle
+// Use of this source code is governed by an MIT-style
+// license that can be found in LICENSE file.
+
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const podsJSON = `{
+	"kind": "List",
+	"items": [
+		{"metadata": {"name": "web-1", "labels": {"app": "web"}}, "status": {"phase": "Running", "restarts": 0}},
+		{"metadata": {"name": "db-1", "labels": {"app": "db"}}, "status": {"phase": "Pending", "restarts": 3}}
+	]
+}`
+
+func pods(t *testing.T) interface{} {
+	var data interface{}
+	if err := json.Unmarshal([]byte(podsJSON), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func render(t *testing.T, text string, data interface{}) (string, error) {
+	t.Helper()
+	tmpl, err := New("test").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.String(), err
+}
+
+func TestTemplate_Execute(t *testing.T) {
+	data := pods(t)
+	tests := []struct {
+		text, want string
+	}{
+		{"kind: {.kind}", "kind: List"},
+		{"{kind}", "List"},
+		{"{.items[*].metadata.name}", "web-1 db-1"},
+		{"{.items[0].status.restarts}", "0"},
+		{"{.items[1].metadata.labels}", `{"app":"db"}`},
+		{"{.items[0].metadata.name}{\"\\t\"}{.items[1].metadata.name}{\"\\n\"}", "web-1\tdb-1\n"},
+		{"{range .items[*]}{.metadata.name}={.status.phase}{\"\\n\"}{end}", "web-1=Running\ndb-1=Pending\n"},
+		{"{range .items}{@.metadata.name},{end}", "web-1,db-1,"},
+		{"{range .items[*]}{.metadata.name}/{$.kind} {end}", "web-1/List db-1/List "},
+		{"{range .items[*]}{range .metadata.labels}{@}{end};{end}", "web;db;"},
+		{"{.items[?(@.status.restarts > 1)].metadata.name}", "db-1"},
+		{"{range .items[*]}[{.metadata.name}]{end}", "[web-1][db-1]"},
+		{"no actions", "no actions"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, err := render(t, tt.text, data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// Whether a path selected one value or a list of them depends on the
+// document, not on the syntax of the path.
+func TestTemplate_ResultShape(t *testing.T) {
+	data := pods(t)
+	var matrix interface{}
+	if err := json.Unmarshal([]byte(`{"m": [[1, 2], [3, 4]]}`), &matrix); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		data interface{}
+		want string
+	}{
+		{"{.items.metadata.name}", data, "web-1 db-1"},
+		{"{range .items.metadata}{.name};{end}", data, "web-1;db-1;"},
+		{"{.m[-1]}", matrix, "[3,4]"},
+		{"{range .m[-1]}{@};{end}", matrix, "3;4;"},
+		{"{.m[0,1]}", matrix, "[1,2] [3,4]"},
+		{"{range .m[0]}{@};{end}", matrix, "1;2;"},
+	}
+	for _, tt := range tests {
+		got, err := render(t, tt.text, tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTemplate_Current(t *testing.T) {
+	got, err := render(t, "{range @}{@} {end}", []interface{}{"a", 1.5, true, nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "a 1.5 true  " {
+		t.Errorf("got %q", got)
+	}
+}
+
+// version is a map key whose text differs from how fmt prints it.
+type version struct{ major, minor int }
+
+func (v version) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("v%02d.%02d", v.major, v.minor)), nil
+}
+
+func TestTemplate_RangeMapKeyOrder(t *testing.T) {
+	data := map[string]interface{}{
+		"releases": map[version]string{{9, 0}: "old", {10, 1}: "new"},
+	}
+	got, err := render(t, "{range .releases}{@};{end}", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "old;new;" {
+		t.Errorf("got %q, want %q", got, "old;new;")
+	}
+	// the order of [*]
+	if got, err := render(t, "{.releases[*]}", data); err != nil || got != "old new" {
+		t.Errorf("got %q, %v, want %q", got, err, "old new")
+	}
+}
+
+func TestTemplate_ParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"{.a",
+		"{range .items[*]}{.a}",
+		"{end}",
+		`{"unterminated\"}`,
+		"{}",
+		"{range}{end}",
+		"{.a[?(@.b == 1}",
+	} {
+		if _, err := New("bad").Parse(text); err == nil {
+			t.Errorf("%q: expected an error", text)
+		} else if !strings.HasPrefix(err.Error(), "template bad:") {
+			t.Errorf("%q: unexpected error %v", text, err)
+		}
+	}
+}
+
+func TestTemplate_MissingKeys(t *testing.T) {
+	data := pods(t)
+	tmpl := Must(New("missing").Parse("[{.spec.nodeName}][{.items[5].metadata.name}]"))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err == nil || !strings.Contains(err.Error(), "{.spec.nodeName}") {
+		t.Errorf("expected a missing key error, got %v", err)
+	}
+
+	buf.Reset()
+	if err := tmpl.AllowMissingKeys(true).Execute(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[][]" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestTemplate_Struct(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+		Size *int   `json:"size,omitempty"`
+	}
+	size := 3
+	data := struct {
+		Items []item `json:"items"`
+	}{Items: []item{{Name: "a", Size: &size}, {Name: "b"}}}
+	got, err := render(t, "{range .items[*]}{.name}:{.size} {end}", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "a:3 b: " {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestMust(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic")
+		}
+	}()
+	Must(New("panic").Parse("{.a"))
+}